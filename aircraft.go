@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"log"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	AIRCRAFT_SPECIAL_NONE = iota
+	AIRCRAFT_SPECIAL_ROLL
+	AIRCRAFT_SPECIAL_BOMBS
+	AIRCRAFT_SPECIAL_SHIELD
+	AIRCRAFT_SPECIAL_WINGMAN
+)
+
+const (
+	AIRCRAFT_FRAMES      = 5
+	AIRCRAFT_EXTRA_BOMBS = 2
+)
+
+var (
+	aircraftSpecials = map[string]int{
+		"NONE":    AIRCRAFT_SPECIAL_NONE,
+		"ROLL":    AIRCRAFT_SPECIAL_ROLL,
+		"BOMBS":   AIRCRAFT_SPECIAL_BOMBS,
+		"SHIELD":  AIRCRAFT_SPECIAL_SHIELD,
+		"WINGMAN": AIRCRAFT_SPECIAL_WINGMAN,
+	}
+	aircraftSheets = map[string][]byte{
+		"airplanePlayer": AirplanePlayer,
+		"airplanes1":     Airplanes1,
+		"airplanes2":     Airplanes2,
+		"airplanes3":     Airplanes3,
+	}
+	// width of each banking frame when a plane only has the one
+	aircraftBankSquash = [AIRCRAFT_FRAMES]float64{0.7, 0.85, 1, 0.85, 0.7}
+	aircraftTable      = loadAircraftTable(AircraftCfg)
+)
+
+// AircraftDef is one line of data/aircraft.cfg
+type AircraftDef struct {
+	name, sheet         string
+	x, y, width, height int
+	frames              int
+	speed               float64
+	health, fuel        int
+	// ticks of invulnerability after a new life and after a hit
+	respawnInvuln, hitInvuln int
+	weapon                   int
+	special                  int
+	specialName, weaponName  string
+}
+
+func loadAircraftTable(data []byte) []AircraftDef {
+	table := []AircraftDef{}
+	for _, fields := range getConfigRecords(data) {
+		if len(fields) < 14 {
+			log.Fatalf("aircraft.cfg: short line %v", fields)
+		}
+		def := AircraftDef{name: fields[0], sheet: fields[1], weaponName: fields[12], specialName: fields[13]}
+		numbers := [9]int{}
+		for i, field := range []string{fields[2], fields[3], fields[4], fields[5], fields[6], fields[8], fields[9], fields[10], fields[11]} {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				log.Fatal(err)
+			}
+			numbers[i] = n
+		}
+		def.x, def.y, def.width, def.height = numbers[0], numbers[1], numbers[2], numbers[3]
+		def.frames, def.health, def.fuel = numbers[4], numbers[5], numbers[6]
+		def.respawnInvuln, def.hitInvuln = numbers[7], numbers[8]
+		speed, err := strconv.ParseFloat(fields[7], 64)
+		if err != nil {
+			log.Fatal(err)
+		}
+		def.speed = speed
+		def.weapon = -1
+		for kind := range weaponTable {
+			if weaponTable[kind].name == def.weaponName {
+				def.weapon = kind
+			}
+		}
+		special, ok := aircraftSpecials[def.specialName]
+		if _, found := aircraftSheets[def.sheet]; !found || !ok || def.weapon < 0 || def.frames < 1 ||
+			def.respawnInvuln < 0 || def.hitInvuln < 0 {
+			log.Fatalf("aircraft.cfg: bad line %v", fields)
+		}
+		def.special = special
+		table = append(table, def)
+	}
+	if len(table) == 0 {
+		log.Fatal("aircraft.cfg: no aircraft")
+	}
+	return table
+}
+
+func loadAircraftImages(def *AircraftDef) []*ebiten.Image {
+	// AIRCRAFT_FRAMES banking frames of PLAYER_SIZE, hard left to hard right
+	img, _, err := image.Decode(bytes.NewReader(aircraftSheets[def.sheet]))
+	if err != nil {
+		log.Fatal(err)
+	}
+	sheet := SubImage(ebiten.NewImageFromImage(img), def.x, def.y, def.width, def.height)
+	frameW := def.width / def.frames
+	images := make([]*ebiten.Image, AIRCRAFT_FRAMES)
+	for i := range images {
+		if def.frames >= AIRCRAFT_FRAMES {
+			images[i] = ScaleImage(SubImage(sheet, i*frameW, 0, frameW, def.height), PLAYER_SIZE, PLAYER_SIZE)
+			continue
+		}
+		// squeeze the one frame about its middle
+		squash := aircraftBankSquash[i]
+		images[i] = ebiten.NewImage(PLAYER_SIZE, PLAYER_SIZE)
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(squash*PLAYER_SIZE/float64(def.width), PLAYER_SIZE/float64(def.height))
+		op.GeoM.Translate(PLAYER_SIZE*(1-squash)/2, 0)
+		op.Filter = ebiten.FilterLinear
+		images[i].DrawImage(sheet, op)
+	}
+	return images
+}
@@ -179,6 +179,11 @@ func (c *Background) Draw(screen *ebiten.Image) {
 	DrawImageAt(c.imageOcean.image, screen, 0, c.backgroundY1)
 	DrawImageAt(c.imageOcean.image, screen, 0, c.backgroundY2)
 
+	// ground and naval targets sit on the ocean, beneath clouds and aircraft
+	if c.game.mode != MENU {
+		c.game.ground.drawLayer(screen)
+	}
+
 	for i := range c.cloudSprites {
 		DrawImageAtF(c.cloudSprites[i].image, screen, c.cloudSprites[i].x, c.cloudSprites[i].y)
 	}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	// swept bodies never move a probe further than this fraction of their size
+	COLLISION_SWEEP_STEP = 0.5
+)
+
+const (
+	LAYER_PLAYER uint32 = 1 << iota
+	LAYER_ENTITY
+	LAYER_PLAYER_SHOT
+	LAYER_ENEMY_SHOT
+	LAYER_PICKUP
+	LAYER_GROUND
+	LAYER_WINGMAN
+	LAYER_BLAST
+)
+
+var (
+	// the layers each layer reacts to touching, a pair collides if either side reacts
+	collisionMaskTable = map[uint32]uint32{
+		// rams are settled for both sides by the plane's handler
+		LAYER_PLAYER:      LAYER_ENEMY_SHOT,
+		LAYER_ENTITY:      LAYER_PLAYER | LAYER_PLAYER_SHOT,
+		LAYER_PLAYER_SHOT: LAYER_ENTITY,
+		LAYER_ENEMY_SHOT:  LAYER_PLAYER | LAYER_WINGMAN,
+		LAYER_PICKUP:      LAYER_PLAYER,
+		LAYER_GROUND:      LAYER_BLAST,
+		LAYER_WINGMAN:     LAYER_ENEMY_SHOT,
+		LAYER_BLAST:       LAYER_GROUND,
+	}
+)
+
+// Body is what an object declares to the collision pass each tick.
+// Handlers registered for its layer get called with every body it reacts to.
+type Body struct {
+	layer, mask uint32
+	// slot in the owner's array
+	index int
+	// dealt to whatever reacts to touching this body
+	damage int
+	// player credited with what this body destroys
+	owner *Player
+	// slots on the layers in mask to skip, piercing shots skip entities they went through
+	ignore  uint64
+	active  *bool
+	movable *Movable
+	hitbox  *Hitbox
+	// swept bodies are tested along their move from lastX, lastY
+	swept        bool
+	lastX, lastY float64
+}
+
+type Contact struct {
+	bodyA, bodyB int
+	// how far along a swept move, 0 to 1, earlier contacts are handled first
+	impact float64
+}
+
+type Collidable interface {
+	addBodies(grid *SpatialGrid)
+}
+
+func NewBody(layer uint32, index int, active *bool, movable *Movable, hitbox *Hitbox) Body {
+	return Body{layer: layer, mask: collisionMaskTable[layer], index: index, active: active, movable: movable, hitbox: hitbox}
+}
+
+func (b *Body) bounds() Movable {
+	if !b.swept {
+		return *b.movable
+	}
+	return SweptBounds(b.movable, b.lastX, b.lastY)
+}
+
+func (b *Body) reacts(other *Body) bool {
+	return b.mask&other.layer != 0
+}
+
+func (b *Body) ignores(other *Body) bool {
+	return b.reacts(other) && b.ignore&(1<<other.index) != 0
+}
+
+func SweptBounds(m *Movable, lastX, lastY float64) Movable {
+	// the area covered moving from lastX, lastY to the current position
+	swept := Movable{}
+	swept.worldX = math.Min(lastX, m.worldX)
+	swept.worldY = math.Min(lastY, m.worldY)
+	swept.width = m.width + int(math.Ceil(math.Abs(m.worldX-lastX)))
+	swept.height = m.height + int(math.Ceil(math.Abs(m.worldY-lastY)))
+	return swept
+}
+
+func SweepHit(m *Movable, lastX, lastY float64, hitbox *Hitbox, target *Movable, targetHitbox *Hitbox) float64 {
+	// fraction of the last move at the first step where m touches target, -1 if it never does
+	// a fraction rather than the step, so bodies sweeping at different speeds compare
+	// targets are treated as standing still at their new position
+	swept := SweptBounds(m, lastX, lastY)
+	if !Intersect(&swept, target) {
+		return -1
+	}
+	stepSize := math.Max(1, float64(min(m.width, m.height))*COLLISION_SWEEP_STEP)
+	distance := math.Hypot(m.worldX-lastX, m.worldY-lastY)
+	steps := max(1, int(math.Ceil(distance/stepSize)))
+	probe := *m
+	for step := 1; step <= steps; step++ {
+		t := float64(step) / float64(steps)
+		probe.worldX = lastX + (m.worldX-lastX)*t
+		probe.worldY = lastY + (m.worldY-lastY)*t
+		if HitTest(&probe, hitbox, target, targetHitbox) {
+			return t
+		}
+	}
+	return -1
+}
+
+func (c *SpatialGrid) handle(layer uint32, handler func(self, other *Body)) {
+	c.handlers[layer] = handler
+}
+
+func (c *SpatialGrid) touch(bodyA, bodyB *Body) float64 {
+	// time of first contact along the move, 0 for bodies that are not swept, -1 if apart
+	switch {
+	case bodyA.swept:
+		return SweepHit(bodyA.movable, bodyA.lastX, bodyA.lastY, bodyA.hitbox, bodyB.movable, bodyB.hitbox)
+	case bodyB.swept:
+		return SweepHit(bodyB.movable, bodyB.lastX, bodyB.lastY, bodyB.hitbox, bodyA.movable, bodyA.hitbox)
+	case HitTest(bodyA.movable, bodyA.hitbox, bodyB.movable, bodyB.hitbox):
+		return 0
+	}
+	return -1
+}
+
+func (c *SpatialGrid) resolve() {
+	// find every touching pair once, then hand them to the layer handlers in order
+	c.contacts = c.contacts[:0]
+	for a := range c.bodies {
+		var bodyA = &c.bodies[a]
+		bounds := bodyA.bounds()
+		c.queryIDs(&bounds, ^uint32(0), func(b int) bool {
+			var bodyB = &c.bodies[b]
+			if b <= a || !(bodyA.reacts(bodyB) || bodyB.reacts(bodyA)) {
+				return true
+			}
+			if bodyA.ignores(bodyB) || bodyB.ignores(bodyA) {
+				return true
+			}
+			if impact := c.touch(bodyA, bodyB); impact >= 0 {
+				c.contacts = append(c.contacts, Contact{a, b, impact})
+			}
+			return true
+		})
+	}
+	sort.SliceStable(c.contacts, func(i, j int) bool {
+		return c.contacts[i].impact < c.contacts[j].impact
+	})
+	for _, contact := range c.contacts {
+		var bodyA, bodyB = &c.bodies[contact.bodyA], &c.bodies[contact.bodyB]
+		// an earlier contact may have used one of them up
+		if !*bodyA.active || !*bodyB.active {
+			continue
+		}
+		if handler := c.handlers[bodyA.layer]; nil != handler && bodyA.reacts(bodyB) {
+			handler(bodyA, bodyB)
+		}
+		if handler := c.handlers[bodyB.layer]; nil != handler && bodyB.reacts(bodyA) {
+			handler(bodyB, bodyA)
+		}
+	}
+}
+
+func (g *Game) resolveCollisions() {
+	g.grid.clear()
+	for _, v := range g.components {
+		if collidable, ok := v.(Collidable); ok {
+			collidable.addBodies(g.grid)
+		}
+	}
+	g.grid.resolve()
+	g.projectile.checkGrazes()
+}
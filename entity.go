@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"image"
 	"log"
+	"math"
 	"math/rand/v2"
 	"time"
 
@@ -24,37 +25,46 @@ const (
 	ENTITY_RAND_INTERVAL_MAX    = 2000
 	ENTITY_START_Y              = -300
 	ENTITY_LOOT_DROP_MAX        = 3
-	ENTITY_START_X_MAX          = WINDOW_WIDTH - ENTITY_W
+	ENTITY_HEALTH               = 25
+	ENTITY_DEBRIS               = 24
+	ENTITY_DRIFT_SPEED          = 0.6
+	ENTITY_DIVE_ACCEL           = 0.02
+	ENTITY_DIVE_SPEED_MAX       = ENTITY_SPEED * 2
 	DIFFICULTY_SPAWN_SPEED_STEP = 200
 	//ENEMY_PROJECTILE_SPEED   = 2
 )
 
 var (
-// projectileColorE = color.RGBA{0xff, 0xff, 0x30, 0xff}
-// projectileColorP = color.RGBA{0xe0, 0xe0, 0x6f, 0xff}
+	// projectileColorE = color.RGBA{0xff, 0xff, 0x30, 0xff}
+	// projectileColorP = color.RGBA{0xe0, 0xe0, 0x6f, 0xff}
+
+	// hitbox per kind, one shape per sprite sheet and its own for each big plane
+	entityHitboxTable = [ENTITY_KINDS]HitboxDef{
+		HITBOX_JET, HITBOX_JET, HITBOX_JET, HITBOX_JET, HITBOX_JET, HITBOX_JET_BIG,
+		HITBOX_PROP, HITBOX_PROP, HITBOX_PROP, HITBOX_PROP, HITBOX_PROP, HITBOX_PROP_BIG,
+		HITBOX_MILITARY, HITBOX_MILITARY, HITBOX_MILITARY, HITBOX_MILITARY, HITBOX_MILITARY, HITBOX_MILITARY_BIG,
+	}
 )
 
 type Entity struct {
 	game                *Game
 	images              [ENTITY_KINDS]ebiten.Image
+	hitboxes            [ENTITY_KINDS]Hitbox
 	entityUnits         [ENTITYS_MAX]EntityUnit
 	lastTimeMilli       int64
 	entitySpawnInterval int64
-	enemyFirePositionY  int
+	enemyFirePositionY  float64
 }
 
 type EntityUnit struct {
-	worldX, worldY, kind int
-	velX, velY           int
-	active, fired        bool
+	kind          int
+	health        int
+	active, fired bool
+	// damaged planes trail smoke
+	smoke ParticleEmitter
 	Movable
 }
 
-func (punit *EntityUnit) Motion() {
-	punit.worldX += punit.velX
-	punit.worldY += punit.velY
-}
-
 func (c *Entity) FireProjectile(eunit *EntityUnit) {
 	if !eunit.fired && c.enemyFirePositionY-eunit.worldY < 3 {
 		// if difficulty is low, abort more often
@@ -63,12 +73,17 @@ func (c *Entity) FireProjectile(eunit *EntityUnit) {
 			eunit.fired = true
 			return
 		}
-		projectileY := eunit.worldY + eunit.height
+		// aim at whichever player is closest
+		target := c.game.nearestPlayer(eunit.Center())
+		if nil == target {
+			return
+		}
+		projectileY := eunit.worldY + float64(eunit.height)
 		var projectileUnit = c.game.projectile.addEnemyProjectile(eunit.worldX, projectileY)
 		if nil != projectileUnit {
 
-			dx := c.game.player.worldX - eunit.worldX
-			// dy := c.game.player.worldY - eunit.worldY
+			dx := target.worldX - eunit.worldX
+			// dy := target.worldY - eunit.worldY
 			// if dy == 0 {
 			// 	dy = 1
 			// }
@@ -87,11 +102,6 @@ func (c *Entity) FireProjectile(eunit *EntityUnit) {
 	}
 }
 
-func (c *EntityUnit) Dimensions() (int, int, int, int) {
-	return c.worldX, c.worldY, c.width, c.height
-	//return 5, 5, 5, 5
-}
-
 func NewEntity(g *Game) *Entity {
 	c := &Entity{}
 	c.game = g
@@ -100,6 +110,7 @@ func NewEntity(g *Game) *Entity {
 	c.entitySpawnInterval = ENTITY_MIN_INTERVAL + int64(DIFFICULTY_SPAWN_SPEED_STEP*c.game.difficulty)
 	c.entityUnits = [ENTITYS_MAX]EntityUnit{}
 	c.initImages()
+	g.grid.handle(LAYER_ENTITY, c.onHit)
 	//c.entityUnits[0] = EntityUnit{200, 200, 4, 0, 1, true}
 	c.addEntity(100, 100, 2)
 	return c
@@ -109,6 +120,16 @@ func (c *Entity) initImages() {
 	var imgI image.Image
 	var img *ebiten.Image
 
+	cut := func(kind, x, y, width, height int) {
+		// sprites are drawn flipped and scaled, the mask has to follow
+		c.images[kind] = *SubImage(img, x, y, width, height)
+		scaledW := int(float64(width) * ENTITY_SCALE)
+		scaledH := int(float64(height) * ENTITY_SCALE)
+		c.hitboxes[kind] = NewHitbox(entityHitboxTable[kind], scaledW, scaledH, imgI, func(dx, dy int) (int, int) {
+			return x + int(float64(dx)/ENTITY_SCALE), y + height - 1 - int(float64(dy)/ENTITY_SCALE)
+		})
+	}
+
 	// SPRITESHEET1 := "jets.png"
 	// SPRITESHEET2 := "airplanes2.png"
 	// SPRITESHEET3 := "airplanes3.png"
@@ -124,13 +145,13 @@ func (c *Entity) initImages() {
 	img = ebiten.NewImageFromImage(imgI)
 	// jets
 	//first row
-	c.images[0] = *SubImage(img, 0, 0, 200, 200)
-	c.images[1] = *SubImage(img, 200, 0, 200, 200)
-	c.images[2] = *SubImage(img, 400, 0, 200, 250)
+	cut(0, 0, 0, 200, 200)
+	cut(1, 200, 0, 200, 200)
+	cut(2, 400, 0, 200, 250)
 	//second row
-	c.images[3] = *SubImage(img, 0, 250, 200, 250)
-	c.images[4] = *SubImage(img, 200, 250, 200, 250)
-	c.images[5] = *SubImage(img, 400, 250, 200, 300)
+	cut(3, 0, 250, 200, 250)
+	cut(4, 200, 250, 200, 250)
+	cut(5, 400, 250, 200, 300)
 
 	// white airplanes
 	// path = filepath.Join(c.game.imageSubdir, SPRITESHEET2)
@@ -144,13 +165,13 @@ func (c *Entity) initImages() {
 	}
 	img = ebiten.NewImageFromImage(imgI)
 	//first row
-	c.images[6] = *SubImage(img, 0, 0, 200, 250)
-	c.images[7] = *SubImage(img, 200, 0, 200, 250)
-	c.images[8] = *SubImage(img, 400, 0, 200, 250)
+	cut(6, 0, 0, 200, 250)
+	cut(7, 200, 0, 200, 250)
+	cut(8, 400, 0, 200, 250)
 	//second row
-	c.images[9] = *SubImage(img, 0, 250, 200, 250)
-	c.images[10] = *SubImage(img, 200, 250, 200, 250)
-	c.images[11] = *SubImage(img, 400, 250, 200, 250)
+	cut(9, 0, 250, 200, 250)
+	cut(10, 200, 250, 200, 250)
+	cut(11, 400, 250, 200, 250)
 
 	// military airplanes
 	// path = filepath.Join(c.game.imageSubdir, SPRITESHEET3)
@@ -164,13 +185,13 @@ func (c *Entity) initImages() {
 	}
 	img = ebiten.NewImageFromImage(imgI)
 	//first row
-	c.images[12] = *SubImage(img, 0, 0, 200, 200)
-	c.images[13] = *SubImage(img, 200, 0, 200, 200)
-	c.images[14] = *SubImage(img, 400, 0, 200, 200)
+	cut(12, 0, 0, 200, 200)
+	cut(13, 200, 0, 200, 200)
+	cut(14, 400, 0, 200, 200)
 	//second row
-	c.images[15] = *SubImage(img, 0, 200, 200, 200)
-	c.images[16] = *SubImage(img, 200, 200, 200, 200)
-	c.images[17] = *SubImage(img, 400, 200, 200, 200)
+	cut(15, 0, 200, 200, 200)
+	cut(16, 200, 200, 200, 200)
+	cut(17, 400, 200, 200, 200)
 
 	for i := range c.images {
 		var height = c.images[i].Bounds().Dy()
@@ -215,9 +236,9 @@ func (c *Entity) Draw(screen *ebiten.Image) {
 
 }
 
-func (c *Entity) thirdOfScreen(screenX int) int {
+func (c *Entity) thirdOfScreen(screenX float64) float64 {
 	// -1 0 or 1 for left middle or right
-	divider12 := WINDOW_WIDTH / 3
+	divider12 := WINDOW_WIDTH / 3.0
 	divider23 := divider12 * 2
 	if screenX < divider12 {
 		return -1
@@ -228,11 +249,11 @@ func (c *Entity) thirdOfScreen(screenX int) int {
 	}
 }
 
-func (c *Entity) drawEntity(screen *ebiten.Image, screenX, screenY, kind int) {
+func (c *Entity) drawEntity(screen *ebiten.Image, screenX, screenY float64, kind int) {
 	var image = &c.images[kind]
 
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate((float64)(screenX), (float64)(screenY))
+	op.GeoM.Translate(screenX, screenY)
 	screen.DrawImage(image, op)
 
 }
@@ -251,7 +272,9 @@ func (c *Entity) projectileInBounds(punit *EntityUnit) bool {
 
 func (c *Entity) addRandomEntity() {
 	kind := rand.IntN(ENTITY_KINDS)
-	worldX := rand.IntN(ENTITY_START_X_MAX)
+	// the whole plane starts on screen, however wide its kind is
+	width := int(float64(c.images[kind].Bounds().Dx()) * ENTITY_SCALE)
+	worldX := float64(rand.IntN(WINDOW_WIDTH - width))
 	c.addEntity(worldX, ENTITY_START_Y, kind)
 
 }
@@ -260,9 +283,10 @@ func (c *Entity) removeAll() {
 	c.entityUnits = [ENTITYS_MAX]EntityUnit{}
 }
 
-func (c *Entity) addEntity(worldX, worldY, kind int) {
+func (c *Entity) addEntity(worldX, worldY float64, kind int) {
 	var puArray = &[ENTITYS_MAX]EntityUnit{}
-	var velX, velY = 0, 0
+	var velX, velY = 0.0, 0.0
+	var accY, maxSpeed = 0.0, 0.0
 	var worldXC, worldYC = worldX, worldY
 	_ = velX
 	_ = velY
@@ -278,7 +302,12 @@ func (c *Entity) addEntity(worldX, worldY, kind int) {
 		puArray = &c.entityUnits
 	}
 	if kind >= 6 && kind < 12 {
-		velX = c.thirdOfScreen(worldXC) * -1
+		velX = c.thirdOfScreen(worldXC) * -ENTITY_DRIFT_SPEED
+	}
+	if kind >= 12 {
+		// military jets dive, speeding up as they come down the screen
+		accY = ENTITY_DIVE_ACCEL
+		maxSpeed = ENTITY_DIVE_SPEED_MAX
 	}
 	var nowMilli = time.Now().UnixMilli()
 	for i := range ENTITYS_MAX {
@@ -288,10 +317,14 @@ func (c *Entity) addEntity(worldX, worldY, kind int) {
 			temp := EntityUnit{}
 			temp.worldX, temp.worldY = worldXC, worldYC
 			temp.velX, temp.velY = velX, velY
+			temp.accY, temp.maxSpeed = accY, maxSpeed
 			temp.kind = kind
+			temp.health = ENTITY_HEALTH
+			temp.smoke = NewParticleEmitter(PARTICLE_SMOKE)
 			temp.active = true
-			temp.width = ENTITY_W
-			temp.height = ENTITY_H
+			// bounds match the drawn sprite, the hitbox narrows it down
+			temp.width = int(float64(c.images[kind].Bounds().Dx()) * ENTITY_SCALE)
+			temp.height = int(float64(c.images[kind].Bounds().Dy()) * ENTITY_SCALE)
 			puArray[i] = temp
 
 			//fmt.Printf("add entity %v %v %v %v %v %v \n ", worldXC, worldYC, kind, velX, velY, true)
@@ -303,6 +336,55 @@ func (c *Entity) addEntity(worldX, worldY, kind int) {
 	}
 }
 
+func (c *Entity) addBodies(grid *SpatialGrid) {
+	for i := range ENTITYS_MAX {
+		var eunit = &c.entityUnits[i]
+		if eunit.active {
+			body := NewBody(LAYER_ENTITY, i, &eunit.active, &eunit.Movable, &c.hitboxes[eunit.kind])
+			body.damage = PLAYER_HIT_ENEMY_DAMAGE
+			grid.insert(body)
+		}
+	}
+}
+
+func (c *Entity) onHit(self, other *Body) {
+	var eunit = &c.entityUnits[self.index]
+	if other.layer != LAYER_PLAYER {
+		c.damageEntity(self.index, other.damage, other.owner)
+		return
+	}
+	// rammed by the player, both are hurt unless the player is blinking or rolling through
+	player := c.game.players[other.index]
+	if player.invulnerable() {
+		return
+	}
+	player.takeDamage(self.damage)
+	c.damageEntity(self.index, eunit.health, player)
+}
+
+func (c *Entity) damageEntity(index, damage int, owner *Player) bool {
+	// true if the entity was destroyed, owner gets the credit
+	var eunit = &c.entityUnits[index]
+	eunit.health -= damage
+	if eunit.health > 0 {
+		return false
+	}
+	eunit.active = false
+	c.game.pickup.dropLoot(*eunit)
+	wx, wy := eunit.worldX, eunit.worldY
+	explosionKind := 0
+	if eunit.kind > 7 {
+		explosionKind = 1
+	}
+	c.game.explosion.addExplosion(wx, wy, explosionKind)
+	centerX, centerY := eunit.Center()
+	c.game.particles.burst(PARTICLE_DEBRIS, centerX, centerY, ENTITY_DEBRIS)
+	if nil != owner {
+		owner.scoreKill(entityScoreTable[eunit.kind])
+	}
+	return true
+}
+
 func (c *Entity) loopEntitys() {
 	for i := range ENTITYS_MAX {
 		// player
@@ -312,6 +394,10 @@ func (c *Entity) loopEntitys() {
 		} else {
 			punit.Motion()
 			c.FireProjectile(punit)
+			if punit.active && punit.health < ENTITY_HEALTH {
+				centerX, centerY := punit.Center()
+				c.game.particles.emit(&punit.smoke, centerX, centerY, -math.Pi/2, 1)
+			}
 		}
 
 	}
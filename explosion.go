@@ -31,25 +31,22 @@ type Explosion struct {
 	game                         *Game
 	imagesE1, imagesE2, imagesE3 []*ebiten.Image
 	explosionUnits               [EXPLOSIONS_MAX]ExplosionUnit
+	chainedUnits                 []ChainedExplosion
 	lastTimeMilli                int64
 	lastTimeFrameMilli           int64
 	testRect                     Movable
 }
 
-type ExplosionUnit struct {
-	worldX, worldY int
-	velX, velY     int
-	frame, kind    int
-	active         bool
-}
-
-func (punit *ExplosionUnit) Motion() {
-	punit.worldX += punit.velX
-	punit.worldY += punit.velY
+// an explosion waiting delay ticks to go off, used for chained sequences
+type ChainedExplosion struct {
+	worldX, worldY float64
+	kind, delay    int
 }
 
-func (punit *ExplosionUnit) Dimensions() (int, int, int, int) {
-	return punit.worldX, punit.worldY, EXPLOSION_W, EXPLOSION_H
+type ExplosionUnit struct {
+	frame, kind int
+	active      bool
+	Movable
 }
 
 func NewExplosion(g *Game) *Explosion {
@@ -59,7 +56,7 @@ func NewExplosion(g *Game) *Explosion {
 	c.lastTimeFrameMilli = time.Now().UnixMilli()
 	c.explosionUnits = [EXPLOSIONS_MAX]ExplosionUnit{}
 	c.initImages()
-	c.testRect = Movable{0, 0, 0, 0, EXPLOSION_W, EXPLOSION_H}
+	c.testRect = Movable{width: EXPLOSION_W, height: EXPLOSION_H}
 
 	return c
 }
@@ -124,7 +121,7 @@ func (c *Explosion) Draw(screen *ebiten.Image) {
 
 }
 
-func (c *Explosion) drawExplosion(screen *ebiten.Image, screenX, screenY, frame, kind int) {
+func (c *Explosion) drawExplosion(screen *ebiten.Image, screenX, screenY float64, frame, kind int) {
 	var image *ebiten.Image
 	switch kind {
 	case 0:
@@ -137,7 +134,7 @@ func (c *Explosion) drawExplosion(screen *ebiten.Image, screenX, screenY, frame,
 		image = c.imagesE2[frame]
 	}
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate((float64)(screenX), (float64)(screenY))
+	op.GeoM.Translate(screenX, screenY)
 	screen.DrawImage(image, op)
 
 }
@@ -154,31 +151,50 @@ func (c *Explosion) projectileInBounds(punit *ExplosionUnit) bool {
 	}
 }
 
-func (c *Explosion) addExplosion(worldX, worldY, kind int) {
-	var puArray = &[EXPLOSIONS_MAX]ExplosionUnit{}
-	var velX, velY = 0, 0
-	var worldXC, worldYC = worldX, worldY
-	_ = velX
-	_ = velY
-	puArray = &c.explosionUnits
+func (c *Explosion) addExplosion(worldX, worldY float64, kind int) {
 	var nowMilli = time.Now().UnixMilli()
+	var limitReached = (nowMilli-c.lastTimeMilli > EXPLOSION_MIN_INTERVAL)
+	if limitReached && c.spawnExplosion(worldX, worldY, kind) {
+		c.lastTimeMilli = nowMilli
+	}
+}
+
+func (c *Explosion) addExplosionChain(worldX, worldY float64, kind, delay int) {
+	// chained explosions ignore the minimum interval between explosions
+	c.chainedUnits = append(c.chainedUnits, ChainedExplosion{worldX, worldY, kind, delay})
+}
+
+func (c *Explosion) spawnExplosion(worldX, worldY float64, kind int) bool {
+	var puArray = &c.explosionUnits
 	for i := range EXPLOSIONS_MAX {
-		var limitReached = (nowMilli-c.lastTimeMilli > EXPLOSION_MIN_INTERVAL)
-		if !puArray[i].active && limitReached {
+		if !puArray[i].active {
 			eunit := ExplosionUnit{}
-			eunit.worldX = worldXC
-			eunit.worldY = worldYC
+			eunit.worldX = worldX
+			eunit.worldY = worldY
+			eunit.width, eunit.height = EXPLOSION_W, EXPLOSION_H
 			eunit.active = true
 			eunit.frame = 0
 			eunit.kind = kind
 			puArray[i] = eunit
-			c.lastTimeMilli = nowMilli
 			c.game.sound.PlaySFX(kind)
 			c.game.sound.StopSFX(4)
-			break
+			return true
+
+		}
+	}
+	return false
+}
 
+func (c *Explosion) loopChain() {
+	pending := c.chainedUnits[:0]
+	for _, chained := range c.chainedUnits {
+		chained.delay -= 1
+		if chained.delay > 0 || !c.spawnExplosion(chained.worldX, chained.worldY, chained.kind) {
+			// wait for the delay, or for a free slot
+			pending = append(pending, chained)
 		}
 	}
+	c.chainedUnits = pending
 }
 
 func (c *Explosion) updateFrame(punit *ExplosionUnit) {
@@ -216,6 +232,7 @@ func (c *Explosion) loopExplosions() {
 func (c *Explosion) Update() error {
 
 	c.loopExplosions()
+	c.loopChain()
 	var err error
 	return err
 }
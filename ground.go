@@ -0,0 +1,252 @@
+package main
+
+import (
+	"image/color"
+	"math/rand/v2"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	GROUND_KINDS             = 3
+	GROUNDS_MAX              = 6
+	GROUND_BORDER            = 300
+	GROUND_START_Y           = -250
+	GROUND_MIN_INTERVAL      = 4000
+	GROUND_RAND_INTERVAL_MAX = 4000
+	GROUND_FLAK_SPEED        = 3
+	GROUND_FIRE_RANGE_Y      = WINDOW_HEIGHT - 150
+	GROUND_BOMB_DAMAGE       = 50
+	GROUND_DEBRIS            = 30
+)
+
+const (
+	GROUND_SHIP = iota
+	GROUND_CARRIER
+	GROUND_FLAK
+)
+
+var (
+	groundHullColor  = color.RGBA{0x60, 0x68, 0x70, 0xff}
+	groundDeckColor  = color.RGBA{0x8a, 0x90, 0x96, 0xff}
+	groundTowerColor = color.RGBA{0x30, 0x34, 0x38, 0xff}
+	groundFlakColor  = color.RGBA{0x7a, 0x20, 0x18, 0xff}
+)
+
+type groundKindStats struct {
+	width, height int
+	health        int
+	fireTicks     int
+	spawnWeight   int
+}
+
+// fireTicks of 0 means the kind never fires
+var groundKindTable = [GROUND_KINDS]groundKindStats{
+	GROUND_SHIP:    {40, 140, 100, 150, 4},
+	GROUND_CARRIER: {70, 220, 250, 0, 1},
+	GROUND_FLAK:    {30, 60, 50, 90, 5},
+}
+
+type Ground struct {
+	game                *Game
+	images              [GROUND_KINDS]*ebiten.Image
+	groundUnits         [GROUNDS_MAX]GroundUnit
+	lastTimeMilli       int64
+	groundSpawnInterval int64
+}
+
+type GroundUnit struct {
+	kind, health, fireTicks int
+	active                  bool
+	Movable
+}
+
+func NewGround(g *Game) *Ground {
+	c := &Ground{}
+	c.game = g
+	c.lastTimeMilli = time.Now().UnixMilli()
+	c.groundSpawnInterval = GROUND_MIN_INTERVAL
+	c.groundUnits = [GROUNDS_MAX]GroundUnit{}
+	c.initImages()
+	g.grid.handle(LAYER_GROUND, c.onHit)
+	return c
+}
+
+func (c *Ground) addBodies(grid *SpatialGrid) {
+	// ground units only react to bomb blasts
+	for i := range GROUNDS_MAX {
+		var gunit = &c.groundUnits[i]
+		if gunit.active {
+			grid.insert(NewBody(LAYER_GROUND, i, &gunit.active, &gunit.Movable, nil))
+		}
+	}
+}
+
+func (c *Ground) initImages() {
+	for kind, stats := range groundKindTable {
+		w, h := float32(stats.width), float32(stats.height)
+		img := ebiten.NewImage(stats.width, stats.height)
+		switch kind {
+		case GROUND_CARRIER:
+			vector.DrawFilledRect(img, 0, 0, w, h, groundHullColor, false)
+			vector.DrawFilledRect(img, 4, 4, w-8, h-8, groundDeckColor, false)
+			vector.StrokeLine(img, w/2, 10, w/2, h-10, 2, color.White, false)
+			vector.DrawFilledRect(img, w-16, h/2-20, 10, 40, groundTowerColor, false)
+		case GROUND_FLAK:
+			vector.DrawFilledRect(img, 0, 0, w, h, groundHullColor, false)
+			vector.DrawFilledCircle(img, w/2, h/2, w/3, groundFlakColor, true)
+			vector.StrokeLine(img, w/2, h/2, w/2, 2, 3, groundTowerColor, false)
+		default:
+			vector.DrawFilledRect(img, 0, 0, w, h, groundHullColor, false)
+			vector.DrawFilledRect(img, 6, h/3, w-12, h/3, groundTowerColor, false)
+			vector.DrawFilledCircle(img, w/2, h/6, w/4, groundDeckColor, true)
+			vector.DrawFilledCircle(img, w/2, h-h/6, w/4, groundDeckColor, true)
+		}
+		c.images[kind] = img
+	}
+}
+
+// Draw is a no-op, ground units are drawn by Background beneath the clouds
+func (c *Ground) Draw(screen *ebiten.Image) {
+}
+
+func (c *Ground) drawLayer(screen *ebiten.Image) {
+	for i := range GROUNDS_MAX {
+		var gunit = &c.groundUnits[i]
+		if gunit.active {
+			screenX, screenY := c.game.WorldToScreen(gunit.worldX, gunit.worldY)
+			DrawImageAtF(c.images[gunit.kind], screen, screenX, screenY)
+		}
+	}
+}
+
+func (c *Ground) unitInBounds(gunit *GroundUnit) bool {
+	// true if unit in bounds
+	if gunit.worldX < -GROUND_BORDER ||
+		gunit.worldX > GROUND_BORDER+WINDOW_WIDTH ||
+		gunit.worldY < -GROUND_BORDER ||
+		gunit.worldY > GROUND_BORDER+WINDOW_HEIGHT {
+		return false
+	} else {
+		return true
+	}
+}
+
+func (c *Ground) randomKind() int {
+	totalWeight := 0
+	for _, stats := range groundKindTable {
+		totalWeight += stats.spawnWeight
+	}
+	roll := rand.IntN(totalWeight)
+	for kind, stats := range groundKindTable {
+		if roll < stats.spawnWeight {
+			return kind
+		}
+		roll -= stats.spawnWeight
+	}
+	return GROUND_FLAK
+}
+
+func (c *Ground) addRandomGround() {
+	var nowMilli = time.Now().UnixMilli()
+	if nowMilli-c.lastTimeMilli < c.groundSpawnInterval {
+		return
+	}
+	kind := c.randomKind()
+	stats := groundKindTable[kind]
+	worldX := float64(rand.IntN(WINDOW_WIDTH - stats.width))
+	if c.addGround(worldX, GROUND_START_Y, kind) != nil {
+		c.groundSpawnInterval = GROUND_MIN_INTERVAL + rand.Int64N(GROUND_RAND_INTERVAL_MAX)
+		c.lastTimeMilli = nowMilli
+	}
+}
+
+func (c *Ground) addGround(worldX, worldY float64, kind int) *GroundUnit {
+	stats := groundKindTable[kind]
+	for i := range GROUNDS_MAX {
+		if !c.groundUnits[i].active {
+			temp := GroundUnit{}
+			temp.worldX, temp.worldY = worldX, worldY
+			// ground targets scroll with the ocean
+			temp.velX, temp.velY = 0, float64(c.game.background.oceanSpeed)
+			temp.width, temp.height = stats.width, stats.height
+			temp.kind = kind
+			temp.health = stats.health
+			temp.fireTicks = stats.fireTicks
+			temp.active = true
+			c.groundUnits[i] = temp
+			return &c.groundUnits[i]
+		}
+	}
+	return nil
+}
+
+func (c *Ground) removeAll() {
+	c.groundUnits = [GROUNDS_MAX]GroundUnit{}
+}
+
+func (c *Ground) fireFlak(gunit *GroundUnit) {
+	stats := groundKindTable[gunit.kind]
+	if stats.fireTicks == 0 || gunit.worldY < 0 || gunit.worldY > GROUND_FIRE_RANGE_Y {
+		return
+	}
+	if gunit.fireTicks > 0 {
+		gunit.fireTicks -= 1
+		return
+	}
+	gunit.fireTicks = stats.fireTicks
+	// fire from the middle of the deck toward the player
+	originX := gunit.worldX + float64(gunit.width)/2
+	originY := gunit.worldY + float64(gunit.height)/2
+	target := c.game.nearestPlayer(originX, originY)
+	if nil == target {
+		return
+	}
+	targetX, targetY := target.Center()
+	projectileUnit := c.game.projectile.addEnemyProjectile(originX, originY)
+	if nil != projectileUnit {
+		projectileUnit.velX, projectileUnit.velY = AimVelocity(originX, originY, targetX, targetY, GROUND_FLAK_SPEED)
+	}
+}
+
+func (c *Ground) onHit(self, other *Body) {
+	// caught in a bomb blast, the bomber gets the credit
+	var gunit = &c.groundUnits[self.index]
+	gunit.health -= other.damage
+	if gunit.health > 0 {
+		return
+	}
+	gunit.active = false
+	c.game.explosion.addExplosion(gunit.worldX, gunit.worldY+float64(gunit.height-EXPLOSION_H)/2, 0)
+	centerX, centerY := gunit.Center()
+	c.game.particles.burst(PARTICLE_DEBRIS, centerX, centerY, GROUND_DEBRIS)
+	if nil != other.owner {
+		other.owner.scoreKill(SCORE_GROUND)
+	}
+}
+
+func (c *Ground) loopGround() {
+	for i := range GROUNDS_MAX {
+		var gunit = &c.groundUnits[i]
+		if !gunit.active {
+			continue
+		}
+		if !c.unitInBounds(gunit) {
+			gunit.active = false
+		} else {
+			gunit.Motion()
+			c.fireFlak(gunit)
+		}
+	}
+}
+
+func (c *Ground) Update() error {
+	if c.game.mode == PLAY {
+		c.loopGround()
+		c.addRandomGround()
+	}
+	var err error
+	return err
+}
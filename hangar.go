@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	HANGAR_TITLE_S  = "HANGAR"
+	HANGAR_HINT_S   = "LEFT RIGHT TO CHOOSE, FIRE TO LAUNCH"
+	HANGAR_TITLE_Y  = 40
+	HANGAR_PLANE_Y  = 130
+	HANGAR_STATS_Y  = HANGAR_PLANE_Y + PLAYER_SIZE + 20
+	HANGAR_LINE_H   = 16
+	HANGAR_CURSOR_W = 140
+	HANGAR_CURSOR_H = 260
+)
+
+var (
+	// cursor and label colour for each player
+	hangarCursorColors = [GAME_PLAYERS]color.RGBA{{0xff, 0xff, 0xff, 0xff}, {0xff, 0xa0, 0x50, 0xff}}
+	hangarCursorLabels = [GAME_PLAYERS]string{"1P", "2P"}
+)
+
+// Hangar is the screen before a run where each player picks an aircraft
+type Hangar struct {
+	game *Game
+	// banking frames per aircraft, players fly with these
+	images       [][]*ebiten.Image
+	statsImages  [][]*ebiten.Image
+	titleImage   *ebiten.Image
+	hintImage    *ebiten.Image
+	cursorImages [GAME_PLAYERS]*ebiten.Image
+	// skips the key press that opened the hangar
+	ready bool
+}
+
+func NewHangar(g *Game) *Hangar {
+	c := &Hangar{}
+	c.game = g
+	rasterstring := g.rasterstring
+	for i := range aircraftTable {
+		def := &aircraftTable[i]
+		c.images = append(c.images, loadAircraftImages(def))
+		lines := []string{
+			def.name,
+			fmt.Sprintf("SPEED %v", def.speed),
+			fmt.Sprintf("HEALTH %v", def.health),
+			fmt.Sprintf("FUEL %v", def.fuel),
+			def.weaponName,
+			def.specialName,
+		}
+		stats := []*ebiten.Image{}
+		for _, line := range lines {
+			stats = append(stats, rasterstring.StringToImage(line))
+		}
+		c.statsImages = append(c.statsImages, stats)
+	}
+	c.titleImage = rasterstring.StringToImage(HANGAR_TITLE_S)
+	c.hintImage = rasterstring.StringToImage(HANGAR_HINT_S)
+	for i, label := range hangarCursorLabels {
+		c.cursorImages[i] = rasterstring.StringToImage(label)
+	}
+	return c
+}
+
+func (g *Game) openHangar() {
+	g.mode = HANGAR
+	g.hangar.ready = false
+	g.setStatusStringToMode()
+}
+
+func (c *Hangar) cardX(index int) int {
+	// center of an aircraft's card, spread evenly across the screen
+	return WINDOW_WIDTH * (index + 1) / (len(aircraftTable) + 1)
+}
+
+func (c *Hangar) choose(player *Player, step int) {
+	count := len(aircraftTable)
+	player.aircraftID = (player.aircraftID + step + count) % count
+	player.setAircraft(player.aircraftID)
+	c.game.sound.PlaySFX(6)
+}
+
+func (c *Hangar) launch() {
+	g := c.game
+	g.mode = PLAY
+	g.setStatusStringToMode()
+	g.resetGame()
+}
+
+func (c *Hangar) Update() error {
+	var err error
+	if !c.ready {
+		c.ready = true
+		return err
+	}
+	for _, player := range c.game.players {
+		if inpututil.IsKeyJustPressed(player.keys.left) {
+			c.choose(player, -1)
+		}
+		if inpututil.IsKeyJustPressed(player.keys.right) {
+			c.choose(player, 1)
+		}
+	}
+	if inpututil.IsKeyJustPressed(c.game.players[0].keys.fire) || inpututil.IsKeyJustPressed(ebiten.KeyEnter) {
+		c.launch()
+		return err
+	}
+	for i, id := range c.game.gamepadIDs {
+		if i > 0 || !ebiten.IsStandardGamepadLayoutAvailable(id) {
+			continue
+		}
+		player := c.game.players[GAME_GAMEPAD_PLAYER]
+		if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonLeftLeft) {
+			c.choose(player, -1)
+		}
+		if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonLeftRight) {
+			c.choose(player, 1)
+		}
+		if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonRightBottom) {
+			c.launch()
+		}
+	}
+	return err
+}
+
+func (c *Hangar) Draw(screen *ebiten.Image) {
+	DrawImageAt(c.titleImage, screen, (WINDOW_WIDTH-c.titleImage.Bounds().Dx())/2, HANGAR_TITLE_Y)
+	DrawImageAt(c.hintImage, screen, (WINDOW_WIDTH-c.hintImage.Bounds().Dx())/2, HANGAR_TITLE_Y+HANGAR_LINE_H*2)
+	for i := range aircraftTable {
+		centerX := c.cardX(i)
+		DrawImageAt(c.images[i][2], screen, centerX-PLAYER_SIZE/2, HANGAR_PLANE_Y)
+		for line, img := range c.statsImages[i] {
+			DrawImageAt(img, screen, centerX-img.Bounds().Dx()/2, HANGAR_STATS_Y+line*HANGAR_LINE_H)
+		}
+	}
+	// a box round each player's pick, the second one inset so both show on the same plane
+	for i, player := range c.game.players {
+		inset := float32(i * 6)
+		x := float32(c.cardX(player.aircraftID)-HANGAR_CURSOR_W/2) + inset
+		y := float32(HANGAR_PLANE_Y-20) + inset
+		clr := hangarCursorColors[i]
+		vector.StrokeRect(screen, x, y, HANGAR_CURSOR_W-inset*2, HANGAR_CURSOR_H-inset*2, 2, clr, false)
+		// labels in opposite corners
+		label := c.cursorImages[i]
+		labelX := float64(x) + 4
+		if i > 0 {
+			labelX = float64(x+HANGAR_CURSOR_W-inset*2) - 4 - float64(label.Bounds().Dx())
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Translate(labelX, float64(y)+4)
+		op.ColorScale.ScaleWithColor(clr)
+		screen.DrawImage(label, op)
+	}
+}
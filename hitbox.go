@@ -0,0 +1,269 @@
+package main
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	HITBOX_RECT = iota
+	HITBOX_BOXES
+	HITBOX_CIRCLE
+)
+
+const (
+	// mask pixels at least this opaque count as solid
+	HITBOX_ALPHA_MIN = 0x80
+)
+
+// HitboxDef is a hitbox in fractions of the object size so it survives rescaling.
+// boxes are x, y, width, height, radius is a fraction of the smaller side.
+type HitboxDef struct {
+	shape     int
+	boxes     [][4]float64
+	radius    float64
+	pixelMask bool
+}
+
+var (
+	// fuselage, wings and tail, noses point down the screen
+	// jets have swept wings and a tall tail
+	HITBOX_JET = HitboxDef{
+		shape: HITBOX_BOXES,
+		boxes: [][4]float64{
+			{0.42, 0.00, 0.16, 0.92},
+			{0.02, 0.28, 0.96, 0.44},
+			{0.28, 0.00, 0.44, 0.28},
+		},
+		pixelMask: true,
+	}
+	HITBOX_JET_BIG = HitboxDef{
+		shape: HITBOX_BOXES,
+		boxes: [][4]float64{
+			{0.42, 0.00, 0.16, 0.94},
+			{0.00, 0.32, 1.00, 0.40},
+			{0.28, 0.00, 0.44, 0.12},
+		},
+		pixelMask: true,
+	}
+	// straight wings set low on a long fuselage
+	HITBOX_PROP = HitboxDef{
+		shape: HITBOX_BOXES,
+		boxes: [][4]float64{
+			{0.42, 0.00, 0.16, 0.96},
+			{0.00, 0.32, 1.00, 0.40},
+			{0.24, 0.00, 0.52, 0.26},
+		},
+		pixelMask: true,
+	}
+	HITBOX_PROP_BIG = HitboxDef{
+		shape: HITBOX_BOXES,
+		boxes: [][4]float64{
+			{0.42, 0.04, 0.16, 0.92},
+			{0.00, 0.34, 1.00, 0.36},
+			{0.32, 0.04, 0.36, 0.12},
+		},
+		pixelMask: true,
+	}
+	// the military planes differ a lot, one wide band covers all their wings
+	HITBOX_MILITARY = HitboxDef{
+		shape: HITBOX_BOXES,
+		boxes: [][4]float64{
+			{0.42, 0.00, 0.16, 0.96},
+			{0.00, 0.20, 1.00, 0.68},
+			{0.28, 0.14, 0.44, 0.20},
+		},
+		pixelMask: true,
+	}
+	// small in its cell, the boxes hug the plane
+	HITBOX_MILITARY_BIG = HitboxDef{
+		shape: HITBOX_BOXES,
+		boxes: [][4]float64{
+			{0.44, 0.34, 0.12, 0.62},
+			{0.14, 0.54, 0.72, 0.24},
+			{0.34, 0.34, 0.32, 0.08},
+		},
+		pixelMask: true,
+	}
+	// a small core hitbox is kinder than the whole sprite
+	HITBOX_PLAYER = HitboxDef{shape: HITBOX_CIRCLE, radius: 0.22}
+)
+
+type HitRect struct {
+	x, y, width, height int
+}
+
+type HitMask struct {
+	width, height int
+	solid         []bool
+}
+
+// Hitbox is a HitboxDef resolved to pixels, offsets are from the object's world position.
+// A nil Hitbox is the object's full bounds.
+type Hitbox struct {
+	shape  int
+	boxes  []HitRect
+	radius float64
+	mask   *HitMask
+}
+
+func NewHitbox(def HitboxDef, width, height int, img image.Image, source func(x, y int) (int, int)) Hitbox {
+	// img and source are only needed when the def asks for a pixel mask
+	c := Hitbox{}
+	c.shape = def.shape
+	switch def.shape {
+	case HITBOX_BOXES:
+		for _, box := range def.boxes {
+			c.boxes = append(c.boxes, HitRect{
+				int(box[0] * float64(width)),
+				int(box[1] * float64(height)),
+				int(box[2] * float64(width)),
+				int(box[3] * float64(height)),
+			})
+		}
+	case HITBOX_CIRCLE:
+		c.radius = def.radius * float64(min(width, height))
+	default:
+		c.shape = HITBOX_RECT
+		c.boxes = []HitRect{{0, 0, width, height}}
+	}
+	if def.pixelMask && nil != img {
+		c.mask = NewHitMask(img, width, height, source)
+	}
+	return c
+}
+
+func NewHitMask(img image.Image, width, height int, source func(x, y int) (int, int)) *HitMask {
+	// source maps an object pixel back to the image pixel drawn there
+	c := &HitMask{width, height, make([]bool, width*height)}
+	bounds := img.Bounds()
+	for y := range height {
+		for x := range width {
+			srcX, srcY := source(x, y)
+			if !image.Pt(srcX, srcY).In(bounds) {
+				continue
+			}
+			_, _, _, alpha := img.At(srcX, srcY).RGBA()
+			c.solid[y*width+x] = alpha>>8 >= HITBOX_ALPHA_MIN
+		}
+	}
+	return c
+}
+
+func (c *HitMask) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= c.width || y >= c.height {
+		return false
+	}
+	return c.solid[y*c.width+x]
+}
+
+func (c *Hitbox) circle(obj Collider) (float64, float64, float64, bool) {
+	// center and radius in world coords, false if not a circle
+	if nil == c || c.shape != HITBOX_CIRCLE {
+		return 0, 0, 0, false
+	}
+	worldX, worldY, width, height := obj.Dimensions()
+	return float64(worldX) + float64(width)/2, float64(worldY) + float64(height)/2, c.radius, true
+}
+
+func (c *Hitbox) rects(obj Collider) []HitRect {
+	// boxes in world coords
+	worldX, worldY, width, height := obj.Dimensions()
+	if nil == c || c.shape == HITBOX_CIRCLE {
+		return []HitRect{{worldX, worldY, width, height}}
+	}
+	output := make([]HitRect, len(c.boxes))
+	for i, box := range c.boxes {
+		output[i] = HitRect{worldX + box.x, worldY + box.y, box.width, box.height}
+	}
+	return output
+}
+
+func (c *Hitbox) contains(obj Collider, worldX, worldY int) bool {
+	// true if the world pixel is inside the shape and solid in the mask
+	if cx, cy, radius, ok := c.circle(obj); ok {
+		if math.Hypot(float64(worldX)+0.5-cx, float64(worldY)+0.5-cy) > radius {
+			return false
+		}
+	}
+	objX, objY, width, height := obj.Dimensions()
+	if nil == c {
+		return HitRect{objX, objY, width, height}.contains(worldX, worldY)
+	}
+	if c.shape != HITBOX_CIRCLE {
+		inside := false
+		for _, box := range c.boxes {
+			if box.contains(worldX-objX, worldY-objY) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			return false
+		}
+	}
+	return nil == c.mask || c.mask.at(worldX-objX, worldY-objY)
+}
+
+func (r HitRect) contains(x, y int) bool {
+	return x >= r.x && y >= r.y && x < r.x+r.width && y < r.y+r.height
+}
+
+func (r HitRect) overlaps(other HitRect) bool {
+	return r.x < other.x+other.width && other.x < r.x+r.width &&
+		r.y < other.y+other.height && other.y < r.y+r.height
+}
+
+func (r HitRect) touchesCircle(cx, cy, radius float64) bool {
+	// closest point of the rect to the center
+	nearX := Clamp(float64(r.x), float64(r.x+r.width), cx)
+	nearY := Clamp(float64(r.y), float64(r.y+r.height), cy)
+	return math.Hypot(nearX-cx, nearY-cy) <= radius
+}
+
+func shapesOverlap(objA Collider, hitA *Hitbox, objB Collider, hitB *Hitbox) bool {
+	ax, ay, ar, aCircle := hitA.circle(objA)
+	bx, by, br, bCircle := hitB.circle(objB)
+	switch {
+	case aCircle && bCircle:
+		return math.Hypot(ax-bx, ay-by) <= ar+br
+	case aCircle:
+		for _, rect := range hitB.rects(objB) {
+			if rect.touchesCircle(ax, ay, ar) {
+				return true
+			}
+		}
+		return false
+	case bCircle:
+		return shapesOverlap(objB, hitB, objA, hitA)
+	}
+	for _, rectA := range hitA.rects(objA) {
+		for _, rectB := range hitB.rects(objB) {
+			if rectA.overlaps(rectB) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func HitTest(objA Collider, hitA *Hitbox, objB Collider, hitB *Hitbox) bool {
+	// true if the hitboxes touch, nil hitboxes use the full bounds
+	if !Intersect(objA, objB) || !shapesOverlap(objA, hitA, objB, hitB) {
+		return false
+	}
+	if (nil == hitA || nil == hitA.mask) && (nil == hitB || nil == hitB.mask) {
+		return true
+	}
+	// pixel test over the overlap of the two bounds
+	ax, ay, aw, ah := objA.Dimensions()
+	bx, by, bw, bh := objB.Dimensions()
+	for y := max(ay, by); y < min(ay+ah, by+bh); y++ {
+		for x := max(ax, bx); x < min(ax+aw, bx+bw); x++ {
+			if hitA.contains(objA, x, y) && hitB.contains(objB, x, y) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bytes"
+	"fmt"
 	"image"
 	"image/color"
 	"log"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 const (
@@ -14,37 +16,104 @@ const (
 	HUD_FUEL_MAX   = 100
 	HUD_HEALTH_MAX = 100
 	HUD_ICON_SIZE  = 15
+	HUD_WEAPON_TS  = "%v L%v"
+	HUD_AMMO_TS    = " %v %v"
+	HUD_GAUGE_W    = 60
+	HUD_GAUGE_GAP  = 20
+	// countdown bar under each running power-up icon
+	HUD_POWERUP_BAR_H = 3
+	HUD_FUEL_LOW_S    = "LOW FUEL"
+	HUD_FUEL_OUT_S    = "NO FUEL"
+	HUD_COMBO_TS      = "X%v CHAIN %v"
+	HUD_GRAZE_S       = "GRAZE"
+	// ticks between low fuel warning beeps
+	HUD_FUEL_BEEP_TICKS = 90
 )
 
 var (
 	healthColor = color.RGBA{0xcf, 0xff, 0x10, 0xef}
 	fuelColor   = color.RGBA{0x10, 0x10, 0x10, 0xef}
+	ammoColor   = color.RGBA{0xff, 0xc0, 0x20, 0xef}
+	heatColor   = color.RGBA{0xff, 0x70, 0x10, 0xef}
+	hotColor    = color.RGBA{0xff, 0x10, 0x10, 0xef}
+	gaugeColor  = color.RGBA{0x10, 0x10, 0x10, 0x80}
+	rollColor   = color.RGBA{0x60, 0xd0, 0xff, 0xc0}
+	comboColor  = color.RGBA{0xff, 0xe0, 0x40, 0xc0}
+	grazeColor  = color.RGBA{0xe0, 0x80, 0xff, 0xc0}
+)
+
+var (
+	// the second player's panel sits on the right, under the score column
+	hudPanelX = [GAME_PLAYERS]int{0, WINDOW_WIDTH - 240}
+	hudPanelY = [GAME_PLAYERS]int{0, HUD_BAR_HEIGHT * 6}
 )
 
 type HUD struct {
-	game                         *Game
+	game                 *Game
+	fuelIcon, healthIcon *ebiten.Image
+	weaponIcon           *ebiten.Image
+	ammoIcon, heatIcon   *ebiten.Image
+	smartBombIcon        *ebiten.Image
+	powerupIcons         [POWERUP_KINDS]*ebiten.Image
+	rollIcon             *ebiten.Image
+	panels               [GAME_PLAYERS]*HUDPanel
+	fuelBeepTicks        int
+}
+
+// HUDPanel is one player's bars, gauges and weapon readout
+type HUDPanel struct {
+	player                       *Player
 	fuelBarImage, healthBarImage *ebiten.Image
-	fuelIcon, healthIcon         *ebiten.Image
-	//health                       int
-	fuel  int
-	barY1 int
-	barY2 int
-	barX  int
-	iconX int
+	weaponRSU                    *RasterstringUnit
+	fuelWarningRSU               *RasterstringUnit
+	fuelWarningPulser            func() bool
+	comboRSU                     *RasterstringUnit
+	grazeRSU                     *RasterstringUnit
+	barY1                        int
+	barY2                        int
+	barY3                        int
+	barY4                        int
+	barY5                        int
+	barY6                        int
+	barY7                        int
+	barX                         int
+	iconX                        int
+	// ammo and heat gauges sit to the right of the health and fuel bars
+	gaugeX     int
+	gaugeIconX int
 }
 
 func NewHUD(g *Game) *HUD {
 	c := &HUD{}
 	c.game = g
 
-	//c.health = HUD_HEALTH_MAX
-	c.fuel = HUD_FUEL_MAX
-	c.setPositions()
 	c.initIconImages()
+	// a banking frame of the player's plane stands in for the roll
+	c.rollIcon = ScaleImage(g.players[0].images[0], HUD_ICON_SIZE, HUD_ICON_SIZE)
+	for i, player := range g.players {
+		c.panels[i] = c.newPanel(player, hudPanelX[i], hudPanelY[i])
+	}
 	c.recalculateBarImages()
+	c.updateWeaponText()
 	return c
 }
 
+func (c *HUD) newPanel(player *Player, originX, originY int) *HUDPanel {
+	p := &HUDPanel{}
+	p.player = player
+	c.setPositions(p, originX, originY)
+	rasterstring := c.game.rasterstring
+	p.weaponRSU = rasterstring.AddRasterStringUnit("", p.barX, p.barY3+(HUD_ICON_SIZE-rasterstring.letterHeight)/2)
+	p.fuelWarningRSU = rasterstring.AddRasterStringUnit(HUD_FUEL_LOW_S, p.iconX, p.barY5)
+	p.fuelWarningRSU.visible = false
+	p.fuelWarningPulser = Pulser(15)
+	p.comboRSU = rasterstring.AddRasterStringUnit("", p.iconX, p.barY6)
+	p.comboRSU.visible = false
+	p.grazeRSU = rasterstring.AddRasterStringUnit(HUD_GRAZE_S, p.iconX, p.barY7)
+	p.grazeRSU.visible = false
+	return p
+}
+
 // func (c *HUD) ReduceHealthBar(amount int) {
 // 	c.health -= amount
 // 	if c.health < 0 {
@@ -66,75 +135,258 @@ func (c *HUD) initIconImages() {
 	//c.images = SpriteCutter(ebitenImage, 100, 100, 5, 1)
 	fuelIconCut := SubImage(ebitenImage, 300, 0, 100, 100)
 	healthIconCut := SubImage(ebitenImage, 500, 0, 100, 100)
+	weaponIconCut := SubImage(ebitenImage, 100, 100, 100, 100)
 	c.healthIcon = ScaleImage(healthIconCut, HUD_ICON_SIZE, HUD_ICON_SIZE)
+	c.weaponIcon = ScaleImage(weaponIconCut, HUD_ICON_SIZE, HUD_ICON_SIZE)
+	ammoIconCut := SubImage(ebitenImage, 200, 100, 100, 100)
+	c.ammoIcon = ScaleImage(ammoIconCut, HUD_ICON_SIZE, HUD_ICON_SIZE)
+	c.heatIcon = newHeatIcon()
+	smartBombIconCut := SubImage(ebitenImage, 300, 100, 100, 100)
+	c.smartBombIcon = ScaleImage(smartBombIconCut, HUD_ICON_SIZE, HUD_ICON_SIZE)
 	c.fuelIcon = ScaleImage(fuelIconCut, HUD_ICON_SIZE, HUD_ICON_SIZE)
+	c.powerupIcons = powerupIcons(c.game.rasterstring, HUD_ICON_SIZE, HUD_ICON_SIZE)
 
 }
 
-func (c *HUD) initImages() {
-	c.fuelBarImage = ebiten.NewImage(HUD_BAR_HEIGHT, HUD_BAR_HEIGHT)
-	c.healthBarImage = ebiten.NewImage(HUD_BAR_HEIGHT, HUD_BAR_HEIGHT)
-
-	c.healthBarImage.Fill(healthColor)
-	c.fuelBarImage.Fill(fuelColor)
+func newHeatIcon() *ebiten.Image {
+	// no thermometer on the icon sheets, so draw one: a bulb and a stem, red inside a white outline
+	const size = float32(HUD_ICON_SIZE)
+	icon := ebiten.NewImage(HUD_ICON_SIZE, HUD_ICON_SIZE)
+	for _, layer := range []struct {
+		inset float32
+		clr   color.Color
+	}{{0, color.White}, {1.5, hotColor}} {
+		vector.DrawFilledRect(icon, size*0.36+layer.inset, size*0.05+layer.inset, size*0.28-layer.inset*2, size*0.65, layer.clr, true)
+		vector.DrawFilledCircle(icon, size/2, size*0.72, size*0.26-layer.inset, layer.clr, true)
+	}
+	return icon
 }
 
 func (c *HUD) recalculateBarImages() {
+	for _, p := range c.panels {
+		c.recalculatePanelBars(p)
+	}
+}
+
+func (c *HUD) recalculatePanelBars(p *HUDPanel) {
 
+	// bars are the same length for every plane, full is HUD_HEALTH_MAX and HUD_FUEL_MAX wide
+	aircraft := p.player.aircraft()
 	// health bar
-	healthW := c.game.health
+	healthW := p.player.health * HUD_HEALTH_MAX / aircraft.health
 	if healthW < 1 {
 		healthW = 1
 	}
 	// fuel
-	c.fuel = c.game.fuel
-	if c.fuel < 1 {
-		c.fuel = 1
+	fuelW := p.player.fuel * HUD_FUEL_MAX / aircraft.fuel
+	if fuelW < 1 {
+		fuelW = 1
 	}
-	c.healthBarImage = ebiten.NewImage(healthW, HUD_BAR_HEIGHT)
-	c.healthBarImage.Fill(healthColor)
+	p.healthBarImage = ebiten.NewImage(healthW, HUD_BAR_HEIGHT)
+	p.healthBarImage.Fill(healthColor)
 
-	c.fuelBarImage = ebiten.NewImage(c.fuel, HUD_BAR_HEIGHT)
-	c.fuelBarImage.Fill(fuelColor)
+	p.fuelBarImage = ebiten.NewImage(fuelW, HUD_BAR_HEIGHT)
+	if p.player.lowOnFuel() {
+		p.fuelBarImage.Fill(hotColor)
+	} else {
+		p.fuelBarImage.Fill(fuelColor)
+	}
 
 }
 
 func (c *HUD) Draw(screen *ebiten.Image) {
+	for _, p := range c.panels {
+		if p.player.active {
+			c.drawPanel(screen, p)
+		}
+	}
+}
+
+func (c *HUD) drawPanel(screen *ebiten.Image, p *HUDPanel) {
 
 	// health icon
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(c.iconX), float64(c.barY1))
+	op.GeoM.Translate(float64(p.iconX), float64(p.barY1))
 
 	screen.DrawImage(c.healthIcon, op)
 
 	// fuel icon
 	op = &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(c.iconX), float64(c.barY2))
+	op.GeoM.Translate(float64(p.iconX), float64(p.barY2))
 
 	screen.DrawImage(c.fuelIcon, op)
 
+	// weapon icon, the weapon name is drawn by rasterstring
+	DrawImageAt(c.weaponIcon, screen, p.iconX, p.barY3)
+
 	op = &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(c.barX), float64(c.barY1))
+	op.GeoM.Translate(float64(p.barX), float64(p.barY1))
 
-	screen.DrawImage(c.healthBarImage, op)
+	screen.DrawImage(p.healthBarImage, op)
 	// fuel bar
 
 	op = &ebiten.DrawImageOptions{}
-	op.GeoM.Translate(float64(c.barX), float64(c.barY2))
+	op.GeoM.Translate(float64(p.barX), float64(p.barY2))
+
+	screen.DrawImage(p.fuelBarImage, op)
+
+	if c.game.ammoLimited() {
+		c.drawAmmoGauges(screen, p)
+	}
+
+	// one icon per smart bomb in stock
+	for i := range p.player.smartBombs {
+		DrawImageAt(c.smartBombIcon, screen, p.iconX+i*(HUD_ICON_SIZE+2), p.barY4)
+	}
+	c.drawPowerups(screen, p)
+
+	// roll cooldown fills back up under the power-ups
+	DrawImageAt(c.rollIcon, screen, p.gaugeIconX, p.barY5)
+	var clr color.Color = rollColor
+	if p.player.rollReady() >= 1 {
+		clr = healthColor
+	}
+	c.drawGauge(screen, p, p.barY5, p.player.rollReady(), clr)
 
-	screen.DrawImage(c.fuelBarImage, op)
+	// time left to keep the chain going
+	if p.player.chain > 0 {
+		c.drawGauge(screen, p, p.barY6, float64(p.player.chainTicks)/SCORE_CHAIN_TICKS, comboColor)
+	}
+	// near misses fill this toward the next smart bomb
+	c.drawGauge(screen, p, p.barY7, float64(p.player.grazeMeter)/SCORE_GRAZE_METER_MAX, grazeColor)
+}
 
+func (c *HUD) drawGauge(screen *ebiten.Image, p *HUDPanel, screenY int, fraction float64, clr color.Color) {
+	x, y := float32(p.gaugeX), float32(screenY)
+	vector.DrawFilledRect(screen, x, y, HUD_GAUGE_W, HUD_BAR_HEIGHT, gaugeColor, false)
+	vector.DrawFilledRect(screen, x, y, float32(HUD_GAUGE_W*Clamp(0, 1, fraction)), HUD_BAR_HEIGHT, clr, false)
 }
 
-func (c *HUD) setPositions() {
-	c.barY1 = HUD_BAR_HEIGHT * 3
-	c.barY2 = HUD_BAR_HEIGHT * 5
-	c.barX = HUD_BAR_HEIGHT * 3
-	c.iconX = HUD_BAR_HEIGHT
+func (c *HUD) drawAmmoGauges(screen *ebiten.Image, p *HUDPanel) {
+	weapon := p.player.weapon
+	def := weapon.def()
 
+	DrawImageAt(c.ammoIcon, screen, p.gaugeIconX, p.barY1)
+	c.drawGauge(screen, p, p.barY1, float64(weapon.clip[weapon.kind])/float64(def.clipSize), ammoColor)
+
+	DrawImageAt(c.heatIcon, screen, p.gaugeIconX, p.barY2)
+	var clr color.Color = heatColor
+	if weapon.overheated {
+		clr = hotColor
+	}
+	c.drawGauge(screen, p, p.barY2, weapon.heat/WEAPON_HEAT_MAX, clr)
+}
+
+func (c *HUD) drawPowerups(screen *ebiten.Image, p *HUDPanel) {
+	// running power-ups left to right, each with its time left shrinking under it
+	powerups := p.player.powerups
+	x := p.gaugeIconX
+	for kind, icon := range c.powerupIcons {
+		if !powerups.active(kind) {
+			continue
+		}
+		if powerups.visible(kind) {
+			DrawImageAt(icon, screen, x, p.barY4)
+		}
+		barY := float32(p.barY4 + HUD_ICON_SIZE + 1)
+		vector.DrawFilledRect(screen, float32(x), barY, HUD_ICON_SIZE, HUD_POWERUP_BAR_H, gaugeColor, false)
+		vector.DrawFilledRect(screen, float32(x), barY, float32(HUD_ICON_SIZE*powerups.fraction(kind)), HUD_POWERUP_BAR_H, ammoColor, false)
+		x += HUD_ICON_SIZE + HUD_BAR_HEIGHT/2
+	}
+}
+
+func (c *HUD) setPositions(p *HUDPanel, originX, originY int) {
+	p.barY1 = originY + HUD_BAR_HEIGHT*3
+	p.barY2 = originY + HUD_BAR_HEIGHT*5
+	p.barY3 = originY + HUD_BAR_HEIGHT*7
+	p.barY4 = originY + HUD_BAR_HEIGHT*9
+	p.barY5 = originY + HUD_BAR_HEIGHT*11
+	p.barY6 = originY + HUD_BAR_HEIGHT*13
+	p.barY7 = originY + HUD_BAR_HEIGHT*15
+	p.barX = originX + HUD_BAR_HEIGHT*3
+	p.iconX = originX + HUD_BAR_HEIGHT
+	p.gaugeIconX = p.barX + HUD_FUEL_MAX + HUD_GAUGE_GAP
+	p.gaugeX = p.gaugeIconX + HUD_ICON_SIZE + HUD_BAR_HEIGHT/2
+
+}
+
+func (c *HUD) updateWeaponText() {
+	for _, p := range c.panels {
+		c.updatePanelWeaponText(p)
+	}
+}
+
+func (c *HUD) updatePanelWeaponText(p *HUDPanel) {
+	p.weaponRSU.visible = p.player.active
+	weapon := p.player.weapon
+	text := fmt.Sprintf(HUD_WEAPON_TS, weapon.def().name, weapon.level())
+	if c.game.ammoLimited() {
+		switch {
+		case weapon.overheated:
+			text += " OVERHEAT"
+		case weapon.reloading():
+			text += " RELOAD"
+		case weapon.def().ammoMax == 0:
+			text += fmt.Sprintf(" %v", weapon.clip[weapon.kind])
+		default:
+			text += fmt.Sprintf(HUD_AMMO_TS, weapon.clip[weapon.kind], weapon.ammo[weapon.kind])
+		}
+	}
+	if text != p.weaponRSU.GetText() {
+		p.weaponRSU.SetText(text)
+	}
+}
+
+func (c *HUD) updateFuelWarning() {
+	// blinking warning per player and a beep every so often while any tank is low
+	anyLow := false
+	for _, p := range c.panels {
+		player := p.player
+		if !player.active || !player.lowOnFuel() || c.game.mode != PLAY {
+			p.fuelWarningRSU.visible = false
+			continue
+		}
+		anyLow = true
+		text := HUD_FUEL_LOW_S
+		if player.outOfFuel() {
+			text = HUD_FUEL_OUT_S
+		}
+		if text != p.fuelWarningRSU.GetText() {
+			p.fuelWarningRSU.SetText(text)
+		}
+		p.fuelWarningRSU.visible = p.fuelWarningPulser()
+	}
+	if !anyLow {
+		c.fuelBeepTicks = 0
+		return
+	}
+	if c.fuelBeepTicks <= 0 {
+		c.game.sound.PlaySFX(6)
+		c.fuelBeepTicks = HUD_FUEL_BEEP_TICKS
+	}
+	c.fuelBeepTicks -= 1
+}
+
+func (c *HUD) updateComboText() {
+	// multiplier and chain length, only while a chain is going, the graze label with its meter
+	for _, p := range c.panels {
+		player := p.player
+		p.grazeRSU.visible = player.active && c.game.mode == PLAY
+		p.comboRSU.visible = player.active && player.chain > 0 && c.game.mode == PLAY
+		if !p.comboRSU.visible {
+			continue
+		}
+		text := fmt.Sprintf(HUD_COMBO_TS, player.multiplier(), player.chain)
+		if text != p.comboRSU.GetText() {
+			p.comboRSU.SetText(text)
+		}
+	}
 }
 
 func (c *HUD) Update() error {
 	var err error
+	c.updateWeaponText()
+	c.updateFuelWarning()
+	c.updateComboText()
 	return err
 }
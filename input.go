@@ -1,10 +1,16 @@
 package main
 
 import (
+	"math"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 )
 
+const (
+	INPUT_STICK_DEADZONE = 0.4
+)
+
 type pos struct {
 	x int
 	y int
@@ -52,10 +58,118 @@ func (input *Input) MouseHandler() {
 	}
 }
 
+func (g *Game) playerKeys(c *Player) {
+	keys := c.keys
+	if !c.active {
+		// fire drops a waiting player in, or spends a continue
+		if g.mode == PLAY && inpututil.IsKeyJustPressed(keys.fire) {
+			c.pressStart()
+		}
+		return
+	}
+	if ebiten.IsKeyPressed(keys.up) {
+		c.motionFlags[0] = true
+	}
+	if ebiten.IsKeyPressed(keys.down) {
+		c.motionFlags[1] = true
+	}
+	if ebiten.IsKeyPressed(keys.left) {
+		c.motionFlags[2] = true
+	}
+	if ebiten.IsKeyPressed(keys.right) {
+		c.motionFlags[3] = true
+	}
+	if ebiten.IsKeyPressed(keys.fire) {
+		c.fireHeld = true
+	}
+	if ebiten.IsKeyPressed(keys.sprint) {
+		c.sprint = true
+	}
+	if g.mode != PLAY {
+		return
+	}
+	if ebiten.IsKeyPressed(keys.bomb) {
+		c.dropBomb()
+	}
+	if inpututil.IsKeyJustPressed(keys.roll) {
+		c.startRoll()
+	}
+	if inpututil.IsKeyJustPressed(keys.weapon) {
+		c.weapon.cycle(1)
+	}
+	if inpututil.IsKeyJustPressed(keys.smartBomb) {
+		g.smartBomb.detonate(c)
+	}
+	if inpututil.IsKeyJustPressed(keys.reload) && g.ammoLimited() {
+		c.weapon.reload()
+	}
+}
+
+func (g *Game) gamepadButtons(c *Player, id ebiten.GamepadID) {
+	// standard layout: stick or dpad to move, A fire, B bomb, X smart bomb, Y weapon,
+	// right trigger sprint, right shoulder reload, left shoulder roll, start or A to join
+	if !c.active {
+		if g.mode == PLAY && (inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonCenterRight) ||
+			inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonRightBottom)) {
+			c.pressStart()
+		}
+		return
+	}
+	stickX := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	stickY := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+	if math.Hypot(stickX, stickY) > INPUT_STICK_DEADZONE {
+		// how far the stick is pushed sets the throttle under inertia flight
+		c.stickX, c.stickY = stickX, stickY
+	}
+	if stickY < -INPUT_STICK_DEADZONE || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftTop) {
+		c.motionFlags[0] = true
+	}
+	if stickY > INPUT_STICK_DEADZONE || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftBottom) {
+		c.motionFlags[1] = true
+	}
+	if stickX < -INPUT_STICK_DEADZONE || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftLeft) {
+		c.motionFlags[2] = true
+	}
+	if stickX > INPUT_STICK_DEADZONE || ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftRight) {
+		c.motionFlags[3] = true
+	}
+	if ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonRightBottom) {
+		c.fireHeld = true
+	}
+	if ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonFrontBottomRight) {
+		c.sprint = true
+	}
+	if g.mode != PLAY {
+		return
+	}
+	if ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonRightRight) {
+		c.dropBomb()
+	}
+	if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonFrontTopLeft) {
+		c.startRoll()
+	}
+	if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonRightTop) {
+		c.weapon.cycle(1)
+	}
+	if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonRightLeft) {
+		g.smartBomb.detonate(c)
+	}
+	if inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonFrontTopRight) && g.ammoLimited() {
+		c.weapon.reload()
+	}
+}
+
 func (g *Game) isKeyJustPressed() {
 	// runs when update isnt being called
 	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
 
+	}
+	if g.mode == PLAY && inpututil.IsKeyJustPressed(ebiten.KeyI) {
+		g.inertia = !g.inertia
+	}
+	// players first, so the key that starts a game from the menu doesn't also join player 2
+	for _, player := range g.players {
+		g.playerKeys(player)
 	}
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
 
@@ -71,15 +185,17 @@ func (g *Game) isKeyJustPressed() {
 		for _, v := range g.keyIDs {
 			switch v {
 			case ebiten.KeySpace:
-				g.player.sprint = true
+				g.players[0].sprint = true
 			case ebiten.KeyEnter:
+				// also player 2's fire, a press held from play must not go on to pick or restart
+				if !inpututil.IsKeyJustPressed(v) {
+					break
+				}
 				if g.mode == MENU {
 					g.menu.keyActivateButton()
 				} else if g.mode == GAMEOVER {
 					g.resetGame()
 				}
-			case ebiten.KeyShift:
-				g.player.sprint = true
 			case ebiten.KeyP:
 				if g.input.modeChangeDelayToggle() {
 					if g.mode == PLAY {
@@ -99,39 +215,26 @@ func (g *Game) isKeyJustPressed() {
 					} else if g.mode == MENU {
 						g.mode = PLAY
 
+						g.setStatusStringToMode()
+					} else if g.mode == HANGAR {
+						g.mode = MENU
 						g.setStatusStringToMode()
 					}
 				}
-			case ebiten.KeyW:
-				g.player.motionFlags[0] = true
-			case ebiten.KeyS:
-				g.player.motionFlags[1] = true
-			case ebiten.KeyA:
-				g.player.motionFlags[2] = true
-			case ebiten.KeyD:
-				g.player.motionFlags[3] = true
 			case ebiten.KeyF:
-				g.player.fireProjectile()
-				g.sound.PlaySFX(4)
-				if g.mode == GAMEOVER {
+				if g.mode == GAMEOVER && inpututil.IsKeyJustPressed(v) {
 					g.resetGame()
 				}
 			case ebiten.KeySemicolon:
-				g.lives = 0
+				g.players[0].lives = 0
 			case ebiten.KeyUp:
-				g.player.motionFlags[0] = true
 				if g.mode == MENU {
 					g.menu.keyChangeButton(true)
 				}
 			case ebiten.KeyDown:
-				g.player.motionFlags[1] = true
 				if g.mode == MENU {
 					g.menu.keyChangeButton(false)
 				}
-			case ebiten.KeyLeft:
-				g.player.motionFlags[2] = true
-			case ebiten.KeyRight:
-				g.player.motionFlags[3] = true
 
 			}
 		}
@@ -139,20 +242,17 @@ func (g *Game) isKeyJustPressed() {
 	}
 
 	g.gamepadIDs = ebiten.AppendGamepadIDs(g.gamepadIDs[:0])
-	for _, g := range g.gamepadIDs {
-		if ebiten.IsStandardGamepadLayoutAvailable(g) {
-			if inpututil.IsStandardGamepadButtonJustPressed(g, ebiten.StandardGamepadButtonRightBottom) {
-
-			}
-			if inpututil.IsStandardGamepadButtonJustPressed(g, ebiten.StandardGamepadButtonRightRight) {
-
+	for i, id := range g.gamepadIDs {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			if i == 0 {
+				g.gamepadButtons(g.players[GAME_GAMEPAD_PLAYER], id)
 			}
 		} else {
 			// The button 0/1 might not be A/B buttons.
-			if inpututil.IsGamepadButtonJustPressed(g, ebiten.GamepadButton0) {
+			if inpututil.IsGamepadButtonJustPressed(id, ebiten.GamepadButton0) {
 
 			}
-			if inpututil.IsGamepadButtonJustPressed(g, ebiten.GamepadButton1) {
+			if inpututil.IsGamepadButtonJustPressed(id, ebiten.GamepadButton1) {
 
 			}
 		}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"log"
+	"math/rand/v2"
+	"strconv"
+)
+
+const (
+	// ticks a player has to be low on health before a kill is sure to drop some
+	LOOT_PITY_TICKS = 600
+	// percent of full health that counts as low
+	LOOT_PITY_HEALTH = 35
+	LOOT_PITY_PICKUP = PICKUP_HEALTH2
+	// percent chance of a drop, by pickup rarity, from enemies no table covers
+	LOOT_FALLBACK_CHANCE = 25
+)
+
+var (
+	lootTables = loadLootTables(LootCfg)
+	// every pickup weighed by its rarity
+	lootFallback = newFallbackLootTable()
+)
+
+// LootTable is a TABLE line of data/loot.cfg and the ENTRY lines after it
+type LootTable struct {
+	name                         string
+	firstKind, lastKind          int
+	minDifficulty, maxDifficulty int
+	chance, drops                int
+	guaranteed                   bool
+	entries                      []LootEntry
+	totalWeight                  int
+}
+
+type LootEntry struct {
+	pickup, weight int
+}
+
+func loadLootTables(data []byte) []*LootTable {
+	tables := []*LootTable{}
+	atoi := func(fields []string, field string) int {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			log.Fatalf("loot.cfg: bad number in %v", fields)
+		}
+		return n
+	}
+	for _, fields := range getConfigRecords(data) {
+		switch {
+		case fields[0] == "TABLE" && len(fields) >= 9:
+			table := &LootTable{name: fields[1]}
+			table.firstKind, table.lastKind = atoi(fields, fields[2]), atoi(fields, fields[3])
+			table.minDifficulty, table.maxDifficulty = atoi(fields, fields[4]), atoi(fields, fields[5])
+			table.chance, table.drops = atoi(fields, fields[6]), atoi(fields, fields[7])
+			table.guaranteed = fields[8] == "yes"
+			tables = append(tables, table)
+		case fields[0] == "ENTRY" && len(fields) >= 2 && len(tables) > 0:
+			pickup := -1
+			for kind := range pickupTable {
+				if pickupTable[kind].name == fields[1] {
+					pickup = kind
+				}
+			}
+			if pickup < 0 {
+				log.Fatalf("loot.cfg: unknown pickup %v", fields)
+			}
+			// the weight defaults to the pickup's rarity
+			weight := pickupTable[pickup].rarity
+			if len(fields) >= 3 {
+				weight = atoi(fields, fields[2])
+			}
+			table := tables[len(tables)-1]
+			entry := LootEntry{pickup, weight}
+			table.entries = append(table.entries, entry)
+			table.totalWeight += entry.weight
+		default:
+			log.Fatalf("loot.cfg: bad line %v", fields)
+		}
+	}
+	return tables
+}
+
+func newFallbackLootTable() *LootTable {
+	table := &LootTable{name: "FALLBACK", chance: LOOT_FALLBACK_CHANCE, drops: 1}
+	for kind := range pickupTable {
+		table.entries = append(table.entries, LootEntry{kind, pickupTable[kind].rarity})
+		table.totalWeight += pickupTable[kind].rarity
+	}
+	return table
+}
+
+func findLootTable(kind, difficulty int) *LootTable {
+	// the first table covering the enemy kind at this difficulty, the fallback if none do
+	for _, table := range lootTables {
+		if kind >= table.firstKind && kind <= table.lastKind &&
+			difficulty >= table.minDifficulty && difficulty <= table.maxDifficulty && table.totalWeight > 0 {
+			return table
+		}
+	}
+	return lootFallback
+}
+
+func (c *LootTable) pick() int {
+	roll := rand.IntN(c.totalWeight)
+	for _, entry := range c.entries {
+		if roll < entry.weight {
+			return entry.pickup
+		}
+		roll -= entry.weight
+	}
+	return c.entries[len(c.entries)-1].pickup
+}
+
+func (c *LootTable) roll() []int {
+	// pickup kinds dropped, at most ENTITY_LOOT_DROP_MAX
+	kinds := []int{}
+	for range c.drops {
+		if rand.IntN(100) < c.chance {
+			kinds = append(kinds, c.pick())
+		}
+	}
+	if c.guaranteed && len(kinds) == 0 {
+		kinds = append(kinds, c.pick())
+	}
+	return kinds[:min(len(kinds), ENTITY_LOOT_DROP_MAX)]
+}
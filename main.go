@@ -2,8 +2,8 @@ package main
 
 import (
 	_ "embed"
-	"fmt"
 	"log"
+	"math"
 	"os"
 	_ "os"
 	"path/filepath"
@@ -18,6 +18,7 @@ const (
 	PAUSED
 	MENU
 	GAMEOVER
+	HANGAR
 )
 
 const (
@@ -33,13 +34,37 @@ const (
 	GAME_LIVES_Y             = 10
 	GAME_SCORE_Y             = 30
 	GAME_MIDDLE_Y            = WINDOW_HEIGHT / 2
-	GAME_POINTS_PER_NEW_LIFE = 30
+	GAME_POINTS_PER_NEW_LIFE = 1000
 	GAME_GODMODE             = false
-	GAME_START_HEALTH        = 100
-	GAME_START_FUEL          = 100
 	GAME_START_MODE          = MENU
 	GAME_START_LIVES         = 3
 	GAME_START_VOLUME        = 0.5
+	// ammo, reloads and gun heat only apply from this difficulty up
+	GAME_LIMITED_AMMO                = true
+	GAME_LIMITED_AMMO_MIN_DIFFICULTY = 4
+	GAME_START_SMARTBOMBS            = 2
+	// fuel only drains from this difficulty up
+	GAME_FUEL_MIN_DIFFICULTY = 3
+	GAME_PLAYERS             = 2
+	// the first gamepad drives this player
+	GAME_GAMEPAD_PLAYER = 1
+	// continues either come from one pool or each player gets their own
+	GAME_CONTINUES        = 2
+	GAME_SHARED_CONTINUES = true
+	// ticks a player out of lives has to take a continue
+	GAME_CONTINUE_TICKS = 600
+	GAME_JOIN_S         = "2P PRESS ENTER"
+	// planes accelerate and drift instead of moving at a fixed speed, I toggles it in game
+	GAME_INERTIA_FLIGHT = false
+)
+
+var (
+	// score and lives text for each player, down the right hand side
+	playerLivesTS    = [GAME_PLAYERS]string{GAME_LIVES_TS, "2P LIVES %v"}
+	playerScoreTS    = [GAME_PLAYERS]string{GAME_SCORE_TS, "2P SCORE %v"}
+	playerContinueTS = [GAME_PLAYERS]string{"CONTINUE %v", "2P CONTINUE %v"}
+	playerTextX      = [GAME_PLAYERS]int{GAME_LIVES_X, WINDOW_WIDTH - 150}
+	playerTextY      = [GAME_PLAYERS]int{GAME_LIVES_Y, GAME_LIVES_Y + 40}
 )
 
 type Component interface {
@@ -48,42 +73,47 @@ type Component interface {
 }
 
 type Movable struct {
-	worldX int
-	worldY int
-	velX   int
-	velY   int
-	width  int
-	height int
+	worldX   float64
+	worldY   float64
+	velX     float64
+	velY     float64
+	accX     float64
+	accY     float64
+	maxSpeed float64
+	width    int
+	height   int
 }
 
 type Game struct {
 	components   []Component
 	input        *Input
 	background   *Background
+	ground       *Ground
 	projectile   *Projectile
 	pickup       *Pickup
 	rasterstring *Rasterstring
-	player       *Player
+	players      [GAME_PLAYERS]*Player
 	explosion    *Explosion
+	particles    *Particles
 	entity       *Entity
 	hud          *HUD
 	sound        *Sound
 	menu         *Menu
+	hangar       *Hangar
+	grid         *SpatialGrid
+	smartBomb    *SmartBomb
+	wingmen      *Wingmen
 	mode         int
 	screenLocX   int
 	screenLocY   int
-	lives        int
-	health       int
-	fuel         int
 	difficulty   int
-	score        int
+	continues    int
+	inertia      bool
 	loaded       bool
 	imageSubdir  string
 	soundSubdir  string
 	statusString string
 	audioContext *audio.Context
-	scoreRSU     *RasterstringUnit
-	livesRSU     *RasterstringUnit
 	statusRSU    *RasterstringUnit
 	middleRSU    *RasterstringUnit
 	//input
@@ -102,11 +132,11 @@ func NewGame() *Game {
 	g.soundSubdir = "sound"
 	g.mode = GAME_START_MODE
 	g.godmode = false
-	g.lives = 3
 	g.difficulty = 5
-	g.health = GAME_START_HEALTH
-	g.fuel = GAME_START_FUEL
+	g.continues = GAME_CONTINUES
+	g.inertia = GAME_INERTIA_FLIGHT
 	g.input = NewInput(g)
+	g.grid = NewSpatialGrid()
 	g.components = []Component{}
 
 	g.statusString = "PLAY"
@@ -114,17 +144,26 @@ func NewGame() *Game {
 	g.background = NewBackground(g)
 	g.components = append(g.components, g.background)
 
+	g.ground = NewGround(g)
+	g.components = append(g.components, g.ground)
+
 	g.rasterstring = NewRasterString(g)
 	g.middleRSU = g.rasterstring.AddRasterStringUnit(GAME_GAMEOVER_STRING, GAME_STATUS_X, GAME_MIDDLE_Y)
-	g.scoreRSU = g.rasterstring.AddRasterStringUnit(fmt.Sprintf(GAME_SCORE_TS, g.score), GAME_SCORE_X, GAME_SCORE_Y)
-	g.livesRSU = g.rasterstring.AddRasterStringUnit(fmt.Sprintf(GAME_LIVES_TS, g.lives), GAME_LIVES_X, GAME_LIVES_Y)
 	g.statusRSU = g.rasterstring.AddRasterStringUnit(g.statusString, GAME_STATUS_X, GAME_LIVES_Y)
 	g.components = append(g.components, g.rasterstring)
 	g.setStatusStringToMode()
 	g.middleRSU.visible = false
 
-	g.player = NewPlayer(g)
-	g.components = append(g.components, g.player)
+	// players fly the planes the hangar loads
+	g.hangar = NewHangar(g)
+	for i := range GAME_PLAYERS {
+		g.players[i] = NewPlayer(g, i)
+		g.components = append(g.components, g.players[i])
+	}
+	g.wingmen = NewWingmen(g)
+	g.components = append(g.components, g.wingmen)
+	g.players[0].join()
+	g.grid.handle(LAYER_PLAYER, g.onPlayerHit)
 
 	g.hud = NewHUD(g)
 	g.components = append(g.components, g.hud)
@@ -132,6 +171,9 @@ func NewGame() *Game {
 	g.explosion = NewExplosion(g)
 	g.components = append(g.components, g.explosion)
 
+	g.particles = NewParticles(g)
+	g.components = append(g.components, g.particles)
+
 	g.projectile = NewProjectile(g)
 	g.components = append(g.components, g.projectile)
 
@@ -141,6 +183,10 @@ func NewGame() *Game {
 	g.entity = NewEntity(g)
 	g.components = append(g.components, g.entity)
 
+	// last so the flash draws over everything
+	g.smartBomb = NewSmartBomb(g)
+	g.components = append(g.components, g.smartBomb)
+
 	g.sound = NewSound(g)
 	g.sound.musicVolume = GAME_START_VOLUME
 	g.sound.sfxVolume = GAME_START_VOLUME
@@ -168,12 +214,17 @@ func (g *Game) Update() error {
 
 			v.Update()
 		}
+		g.resolveCollisions()
 		g.didDraw = false
 	}
 	if g.mode == MENU {
 		g.background.Update()
 		g.menu.Update()
 	}
+	if g.mode == HANGAR {
+		g.background.Update()
+		g.hangar.Update()
+	}
 
 	return nil
 }
@@ -181,7 +232,7 @@ func (g *Game) Update() error {
 func (g *Game) Draw(screen *ebiten.Image) {
 	ebitenutil.DebugPrint(screen, WINDOW_TITLE)
 
-	if g.mode != MENU {
+	if g.mode != MENU && g.mode != HANGAR {
 		for _, v := range g.components {
 
 			v.Draw(screen)
@@ -191,38 +242,65 @@ func (g *Game) Draw(screen *ebiten.Image) {
 		g.background.Draw(screen)
 		g.menu.Draw(screen)
 	}
-
-}
-
-func (g *Game) incrementScore() {
-	g.score += 1
-	g.scoreRSU.SetText(fmt.Sprintf(GAME_SCORE_TS, g.score))
-	if g.score%GAME_POINTS_PER_NEW_LIFE == 0 {
-		g.incrementLives()
+	if g.mode == HANGAR {
+		g.background.Draw(screen)
+		g.hangar.Draw(screen)
 	}
-}
 
-func (g *Game) resetScore() {
-	g.score = 0
-	g.scoreRSU.SetText(fmt.Sprintf(GAME_SCORE_TS, g.score))
 }
 
 func (g *Game) resetGame() {
-	g.resetScore()
 	if g.mode == GAMEOVER {
 		g.mode = PLAY
 		g.middleRSU.visible = false
 	}
-	g.lives = GAME_START_LIVES
-	g.health = GAME_START_HEALTH
-	g.fuel = GAME_START_FUEL
+	g.continues = GAME_CONTINUES
+	for _, player := range g.players {
+		player.leave()
+	}
+	g.players[0].join()
 	g.entity.removeAll()
-	g.livesRSU.SetText(fmt.Sprintf(GAME_LIVES_TS, g.lives))
+	g.ground.removeAll()
+	g.particles.clear()
+	g.hud.recalculateBarImages()
 }
 
-func (g *Game) incrementLives() {
-	g.lives += 1
-	g.livesRSU.SetText(fmt.Sprintf(GAME_LIVES_TS, g.lives))
+func (g *Game) nearestPlayer(worldX, worldY float64) *Player {
+	// the player in play closest to a point, nil if nobody is playing
+	var nearest *Player
+	bestDistance := math.Inf(1)
+	for _, player := range g.players {
+		if !player.active {
+			continue
+		}
+		centerX, centerY := player.Center()
+		distance := math.Hypot(centerX-worldX, centerY-worldY)
+		if distance < bestDistance {
+			bestDistance = distance
+			nearest = player
+		}
+	}
+	return nearest
+}
+
+func (g *Game) checkGameOver() {
+	// the game ends once nobody is left playing or waiting to continue
+	for _, player := range g.players {
+		if player.active || player.continueTicks > 0 {
+			return
+		}
+	}
+	g.mode = GAMEOVER
+	g.setStatusStringToMode()
+	g.middleRSU.visible = true
+}
+
+func (g *Game) ammoLimited() bool {
+	return GAME_LIMITED_AMMO && g.difficulty >= GAME_LIMITED_AMMO_MIN_DIFFICULTY
+}
+
+func (g *Game) fuelLimited() bool {
+	return g.difficulty >= GAME_FUEL_MIN_DIFFICULTY
 }
 
 func (g *Game) setStatusString(newStatus string) {
@@ -239,16 +317,13 @@ func (g *Game) setStatusStringToMode() {
 		statusString = "PAUSED"
 	case MENU:
 		statusString = "MENU"
+	case HANGAR:
+		statusString = "HANGAR"
 	}
 
 	g.statusRSU.SetText(statusString)
 }
 
-func (g *Game) decrementLives() {
-	g.lives -= 1
-	g.livesRSU.SetText(fmt.Sprintf(GAME_LIVES_TS, g.lives))
-}
-
 func (g *Game) Layout(outsideWidth, outsideHeight int) (screenWidth, screenHeight int) {
 	return WINDOW_WIDTH, WINDOW_HEIGHT
 }
@@ -331,9 +331,7 @@ func (c *Menu) buttonPressAction(buttonID int) {
 	case 0:
 		if c.menuMode == MAINMENU {
 
-			c.game.mode = PLAY
-			c.game.setStatusStringToMode()
-			c.game.resetGame()
+			c.game.openHangar()
 		} else if c.menuMode == OPTIONSMENU {
 			change := c.clickLeftOrRightOfButton()
 			c.music = Clamp(0, NUMBER_MAX, change+c.music)
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	PARTICLES_MAX = 800
+	// a burst never takes more than this share of the pool
+	PARTICLE_BURST_MAX = 80
+)
+
+const (
+	PARTICLE_DEBRIS = iota
+	PARTICLE_SMOKE
+	PARTICLE_EXHAUST
+	PARTICLE_SPARK
+	PARTICLE_KINDS
+)
+
+// ParticleDef is how one kind of particle starts out, moves and fades
+type ParticleDef struct {
+	// launch speed range, spread is the angle either side of the emit direction
+	speedMin, speedMax float64
+	spread             float64
+	gravity, drag      float64
+	lifeMin, lifeMax   int
+	size, grow         float64
+	// colour at birth fading to the end colour, alpha with it
+	startColor, endColor color.RGBA
+	// particles per tick from a continuous emitter at full rate
+	rate float64
+}
+
+var (
+	particleTable = [PARTICLE_KINDS]ParticleDef{
+		PARTICLE_DEBRIS: {1.5, 4.5, math.Pi, 0.12, 0.98, 30, 60, 3, 0,
+			color.RGBA{0x60, 0x58, 0x50, 0xff}, color.RGBA{0x20, 0x20, 0x20, 0x00}, 0},
+		PARTICLE_SMOKE: {0.2, 0.6, 0.5, -0.02, 0.97, 40, 70, 4, 0.12,
+			color.RGBA{0x70, 0x70, 0x70, 0xa0}, color.RGBA{0x30, 0x30, 0x30, 0x00}, 0.5},
+		PARTICLE_EXHAUST: {0.3, 1, 0.3, 0, 0.9, 12, 20, 3, 0.1,
+			color.RGBA{0xff, 0xd0, 0x60, 0xe0}, color.RGBA{0x80, 0x80, 0x80, 0x00}, 1},
+		PARTICLE_SPARK: {2, 5, math.Pi, 0.05, 0.9, 8, 16, 2, -0.05,
+			color.RGBA{0xff, 0xff, 0xa0, 0xff}, color.RGBA{0xff, 0x60, 0x10, 0x00}, 0},
+	}
+)
+
+// Particles owns the pool every emitter and burst draws from
+type Particles struct {
+	game  *Game
+	image *ebiten.Image
+	units [PARTICLES_MAX]ParticleUnit
+	// where to look for a free particle first, the oldest one is overwritten when none are
+	next int
+}
+
+type ParticleUnit struct {
+	kind       int
+	ticks      int
+	life       int
+	size       float64
+	gravity    float64
+	drag       float64
+	worldX     float64
+	worldY     float64
+	velX, velY float64
+	active     bool
+}
+
+// ParticleEmitter is a continuous source, owners keep one and emit from it every tick
+type ParticleEmitter struct {
+	kind int
+	// particles per tick, fractions carry over to the next tick
+	rate, carry float64
+}
+
+func NewParticles(g *Game) *Particles {
+	c := &Particles{}
+	c.game = g
+	c.image = ebiten.NewImage(1, 1)
+	c.image.Fill(color.White)
+	return c
+}
+
+func NewParticleEmitter(kind int) ParticleEmitter {
+	return ParticleEmitter{kind: kind, rate: particleTable[kind].rate}
+}
+
+func (c *Particles) spawn(kind int, worldX, worldY, angle float64) {
+	def := &particleTable[kind]
+	var punit = &c.units[c.next]
+	for range PARTICLES_MAX {
+		if !c.units[c.next].active {
+			punit = &c.units[c.next]
+			break
+		}
+		c.next = (c.next + 1) % PARTICLES_MAX
+	}
+	c.next = (c.next + 1) % PARTICLES_MAX
+	heading := angle + (rand.Float64()*2-1)*def.spread
+	speed := def.speedMin + rand.Float64()*(def.speedMax-def.speedMin)
+	*punit = ParticleUnit{kind: kind, life: def.lifeMin + rand.IntN(def.lifeMax-def.lifeMin+1),
+		size: def.size, gravity: def.gravity, drag: def.drag, worldX: worldX, worldY: worldY,
+		velX: math.Cos(heading) * speed, velY: math.Sin(heading) * speed, active: true}
+}
+
+func (c *Particles) burst(kind int, worldX, worldY float64, count int) {
+	// count particles at once, flying out all around
+	for range min(count, PARTICLE_BURST_MAX) {
+		c.spawn(kind, worldX, worldY, rand.Float64()*2*math.Pi)
+	}
+}
+
+func (c *Particles) emit(emitter *ParticleEmitter, worldX, worldY, angle, scale float64) {
+	// scale turns the emitter's rate up or down for this tick
+	emitter.carry += emitter.rate * scale
+	for emitter.carry >= 1 {
+		emitter.carry -= 1
+		c.spawn(emitter.kind, worldX, worldY, angle)
+	}
+}
+
+func (c *Particles) clear() {
+	c.units = [PARTICLES_MAX]ParticleUnit{}
+}
+
+func (c *Particles) Update() error {
+	var err error
+	for i := range c.units {
+		var punit = &c.units[i]
+		if !punit.active {
+			continue
+		}
+		punit.ticks += 1
+		if punit.ticks >= punit.life {
+			punit.active = false
+			continue
+		}
+		punit.velX *= punit.drag
+		punit.velY = punit.velY*punit.drag + punit.gravity
+		punit.worldX += punit.velX
+		punit.worldY += punit.velY
+		punit.size = math.Max(1, punit.size+particleTable[punit.kind].grow)
+	}
+	return err
+}
+
+func (c *Particles) Draw(screen *ebiten.Image) {
+	for i := range c.units {
+		var punit = &c.units[i]
+		if !punit.active {
+			continue
+		}
+		def := &particleTable[punit.kind]
+		fade := float32(punit.ticks) / float32(punit.life)
+		lerp := func(a, b uint8) float32 {
+			return (float32(a)*(1-fade) + float32(b)*fade) / 0xff
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(punit.size, punit.size)
+		screenX, screenY := c.game.WorldToScreen(punit.worldX-punit.size/2, punit.worldY-punit.size/2)
+		op.GeoM.Translate(screenX, screenY)
+		// premultiplied, the colour scales down with the alpha
+		alpha := lerp(def.startColor.A, def.endColor.A)
+		op.ColorScale.Scale(lerp(def.startColor.R, def.endColor.R)*alpha, lerp(def.startColor.G, def.endColor.G)*alpha,
+			lerp(def.startColor.B, def.endColor.B)*alpha, alpha)
+		screen.DrawImage(c.image, op)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"image"
 	"log"
+	"math"
 	"math/rand/v2"
 
 	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/colorm"
 )
 
 const (
@@ -14,9 +16,28 @@ const (
 	PICKUP_W           = 30
 	PICKUP_DROP_OFFSET = 50
 	PICKUPS_MAX        = 10
-	PICKUP_KINDS       = 4
+	PICKUP_KINDS       = 15
+	PICKUP_AMMO_REFILL = 0.5
 	PICKUP_DURATION    = 500
-	PICKUP_DROP_FREQ   = 4
+	// horizontal gap between pickups dropped together
+	PICKUP_DROP_SPREAD = 40
+	// pickups this close to a player fly to them, from much further with a magnet running
+	PICKUP_ATTRACT_RADIUS = 60
+	PICKUP_MAGNET_RADIUS  = 220
+	PICKUP_MAGNET_SPEED   = 5
+	// pickups float up and down this many pixels, once every PICKUP_BOB_TICKS
+	PICKUP_BOB_HEIGHT = 4
+	PICKUP_BOB_TICKS  = 60
+	// blinking for the last ticks of a pickup's life, faster towards the end
+	PICKUP_BLINK_TICKS      = 120
+	PICKUP_BLINK_FAST_TICKS = 50
+	// kinds with a glyph draw it over the blank cell of icons2.png, recoloured
+	PICKUP_ICON_CELL    = 100
+	PICKUP_BLANK_X      = 500
+	PICKUP_BLANK_Y      = 100
+	PICKUP_BLANK_HUE    = 165
+	PICKUP_GLYPH_HEIGHT = 40
+	PICKUP_GLYPH_WIDTH  = 84
 )
 
 const (
@@ -24,23 +45,106 @@ const (
 	PICKUP_HEALTH2
 	PICKUP_FUEL1
 	PICKUP_FUEL2
+	PICKUP_WEAPON
+	PICKUP_AMMO
+	PICKUP_SMARTBOMB
+	PICKUP_SHIELD
+	PICKUP_SPEED
+	PICKUP_RAPID
+	PICKUP_DOUBLE
+	PICKUP_WINGMAN
+	PICKUP_LIFE
+	PICKUP_MEDAL
+	PICKUP_MAGNET
 )
 
+// PickupDef is one entry of the pickup registry.
+// rarity weighs the kind when no loot table covers an enemy and for loot entries without a weight.
+type PickupDef struct {
+	name string
+	// cell of icons2.png, or letters over the blank cell turned to hue, in degrees
+	iconX, iconY int
+	glyph        string
+	hue          float64
+	rarity       int
+	// played on collection, -1 for silence
+	sound  int
+	effect func(c *Pickup, player *Player)
+}
+
+var pickupTable = [PICKUP_KINDS]PickupDef{
+	PICKUP_HEALTH1: {"HEALTH1", 0, 0, "", 0, 30, 6, func(c *Pickup, player *Player) {
+		player.heal(25)
+	}},
+	PICKUP_HEALTH2: {"HEALTH2", 100, 0, "", 0, 15, 6, func(c *Pickup, player *Player) {
+		player.heal(35)
+	}},
+	PICKUP_FUEL1: {"FUEL1", 0, 200, "", 0, 25, 3, func(c *Pickup, player *Player) {
+		player.refuel(25)
+	}},
+	PICKUP_FUEL2: {"FUEL2", 100, 200, "", 0, 10, 3, func(c *Pickup, player *Player) {
+		player.refuel(55)
+	}},
+	PICKUP_WEAPON: {"WEAPON", PICKUP_BLANK_X, PICKUP_BLANK_Y, "WP", 30, 10, 5, func(c *Pickup, player *Player) {
+		player.weapon.upgrade()
+	}},
+	PICKUP_AMMO: {"AMMO", PICKUP_BLANK_X, PICKUP_BLANK_Y, "AM", 80, 20, 4, func(c *Pickup, player *Player) {
+		player.weapon.addAmmo(PICKUP_AMMO_REFILL)
+	}},
+	PICKUP_SMARTBOMB: {"SMARTBOMB", PICKUP_BLANK_X, PICKUP_BLANK_Y, "SB", 0, 5, 5, func(c *Pickup, player *Player) {
+		player.addSmartBomb()
+	}},
+	PICKUP_SHIELD: {"SHIELD", PICKUP_BLANK_X, PICKUP_BLANK_Y, "SH", 205, 6, 6, func(c *Pickup, player *Player) {
+		player.powerups.add(POWERUP_SHIELD)
+	}},
+	PICKUP_SPEED: {"SPEED", PICKUP_BLANK_X, PICKUP_BLANK_Y, "SP", 165, 6, 3, func(c *Pickup, player *Player) {
+		player.powerups.add(POWERUP_SPEED)
+	}},
+	PICKUP_RAPID: {"RAPID", PICKUP_BLANK_X, PICKUP_BLANK_Y, "RF", 325, 5, 4, func(c *Pickup, player *Player) {
+		player.powerups.add(POWERUP_RAPID)
+	}},
+	PICKUP_DOUBLE: {"DOUBLE", PICKUP_BLANK_X, PICKUP_BLANK_Y, "2X", 290, 4, 5, func(c *Pickup, player *Player) {
+		player.powerups.add(POWERUP_DOUBLE)
+	}},
+	PICKUP_WINGMAN: {"WINGMAN", PICKUP_BLANK_X, PICKUP_BLANK_Y, "WM", 245, 3, 4, func(c *Pickup, player *Player) {
+		c.game.wingmen.add(player)
+	}},
+	PICKUP_LIFE: {"LIFE", PICKUP_BLANK_X, PICKUP_BLANK_Y, "1UP", 120, 1, 6, func(c *Pickup, player *Player) {
+		player.lives += 1
+		player.updateText()
+	}},
+	PICKUP_MEDAL: {"MEDAL", PICKUP_BLANK_X, PICKUP_BLANK_Y, "$", 55, 8, 6, func(c *Pickup, player *Player) {
+		player.addScore(SCORE_MEDAL)
+	}},
+	PICKUP_MAGNET: {"MAGNET", 300, 100, "", 0, 4, 3, func(c *Pickup, player *Player) {
+		player.powerups.add(POWERUP_MAGNET)
+	}},
+}
+
 type Pickup struct {
 	game         *Game
 	pickupImages [PICKUP_KINDS]*ebiten.Image
 	pickupUnits  [PICKUPS_MAX]*PickupUnit
 
 	lastTimeMilli int64
+	// counts up while a player is low on health, see LOOT_PITY_TICKS
+	pityTicks int
 }
 
 type PickupUnit struct {
-	worldX, worldY, kind, life int
-	active                     bool
+	kind, life int
+	active     bool
+	// start of the bob, so pickups dropped together don't bob in step
+	bobPhase float64
+	Movable
 }
 
-func (punit *PickupUnit) Dimensions() (int, int, int, int) {
-	return punit.worldX, punit.worldY, PICKUP_W, PICKUP_H
+func NewPickupUnit(worldX, worldY float64, kind int) *PickupUnit {
+	punit := &PickupUnit{kind: kind, life: PICKUP_DURATION, active: true}
+	punit.worldX, punit.worldY = worldX, worldY
+	punit.width, punit.height = PICKUP_W, PICKUP_H
+	punit.bobPhase = rand.Float64() * 2 * math.Pi
+	return punit
 }
 
 func NewPickup(g *Game) *Pickup {
@@ -49,6 +153,7 @@ func NewPickup(g *Game) *Pickup {
 	c.pickupUnits = [PICKUPS_MAX]*PickupUnit{}
 	c.pickupImages = [PICKUP_KINDS]*ebiten.Image{}
 	c.initImages()
+	g.grid.handle(LAYER_PICKUP, c.onHit)
 
 	return c
 }
@@ -60,36 +165,58 @@ func (c *Pickup) initImages() {
 		log.Fatal(err)
 	}
 	ebitenImage := ebiten.NewImageFromImage(img)
-	subImageA := SubImage(ebitenImage, 0, 0, 100, 100)
-	subImageB := SubImage(ebitenImage, 100, 0, 100, 100)
-	subImageC := SubImage(ebitenImage, 0, 200, 100, 100)
-	subImageD := SubImage(ebitenImage, 100, 200, 100, 100)
-	c.pickupImages[0] = ScaleImage(subImageA, PICKUP_W, PICKUP_H)
-	c.pickupImages[1] = ScaleImage(subImageB, PICKUP_W, PICKUP_H)
-	c.pickupImages[2] = ScaleImage(subImageC, PICKUP_W, PICKUP_H)
-	c.pickupImages[3] = ScaleImage(subImageD, PICKUP_W, PICKUP_H)
+	for kind := range pickupTable {
+		c.pickupImages[kind] = pickupIcon(ebitenImage, c.game.rasterstring, &pickupTable[kind], PICKUP_W, PICKUP_H)
+	}
 
 }
 
+func pickupIcon(sheet *ebiten.Image, rasterstring *Rasterstring, def *PickupDef, width, height int) *ebiten.Image {
+	// the def's cell as it is, or its glyph on the blank cell so it can't pass for health or fuel
+	cell := SubImage(sheet, def.iconX, def.iconY, PICKUP_ICON_CELL, PICKUP_ICON_CELL)
+	if def.glyph == "" {
+		return ScaleImage(cell, width, height)
+	}
+	icon := ebiten.NewImage(PICKUP_ICON_CELL, PICKUP_ICON_CELL)
+	cm := colorm.ColorM{}
+	cm.ChangeHSV((def.hue-PICKUP_BLANK_HUE)*math.Pi/180, 1, 1)
+	colorm.DrawImage(icon, cell, cm, &colorm.DrawImageOptions{})
+	glyph := rasterstring.StringToImage(def.glyph)
+	glyphW, glyphH := float64(glyph.Bounds().Dx()), float64(glyph.Bounds().Dy())
+	scale := math.Min(PICKUP_GLYPH_HEIGHT/glyphH, PICKUP_GLYPH_WIDTH/glyphW)
+	x, y := (PICKUP_ICON_CELL-glyphW*scale)/2, (PICKUP_ICON_CELL-glyphH*scale)/2
+	// dark drop shadow first so the letters read on any colour
+	for _, offset := range []float64{4, 0} {
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(scale, scale)
+		op.GeoM.Translate(x+offset, y+offset)
+		if offset > 0 {
+			op.ColorScale.Scale(0, 0, 0, 0.6)
+		}
+		icon.DrawImage(glyph, op)
+	}
+	return ScaleImage(icon, width, height)
+}
+
 func (c *Pickup) Draw(screen *ebiten.Image) {
 	for i := range PICKUPS_MAX {
 
 		var pickup = c.pickupUnits[i]
-		if nil != pickup && pickup.active {
+		if nil != pickup && pickup.active && !pickup.blinkedOut() {
 			screenX, screenY := c.game.WorldToScreen(pickup.worldX, pickup.worldY)
 
-			c.drawPickup(screen, screenX, screenY, pickup.kind)
+			c.drawPickup(screen, screenX, screenY+pickup.bob(), pickup.kind)
 		}
 
 	}
 
 }
 
-func (c *Pickup) drawPickup(screen *ebiten.Image, screenX, screenY, kind int) {
+func (c *Pickup) drawPickup(screen *ebiten.Image, screenX, screenY float64, kind int) {
 	var image *ebiten.Image
 	image = c.pickupImages[kind]
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate((float64)(screenX), (float64)(screenY))
+	op.GeoM.Translate(screenX, screenY)
 	screen.DrawImage(image, op)
 
 }
@@ -106,11 +233,11 @@ func (c *Pickup) InBounds(punit *PickupUnit) bool {
 	}
 }
 
-func (c *Pickup) addPickup(worldX, worldY, kind int) *PickupUnit {
+func (c *Pickup) addPickup(worldX, worldY float64, kind int) *PickupUnit {
 
-	for i := range PROJECTILES_MAX {
+	for i := range PICKUPS_MAX {
 		if nil == c.pickupUnits[i] || !c.pickupUnits[i].active {
-			c.pickupUnits[i] = &PickupUnit{worldX, worldY, kind, PICKUP_DURATION, true}
+			c.pickupUnits[i] = NewPickupUnit(worldX, worldY, kind)
 
 			return c.pickupUnits[i]
 
@@ -119,57 +246,86 @@ func (c *Pickup) addPickup(worldX, worldY, kind int) *PickupUnit {
 	return nil
 }
 
-func (c *Pickup) dropLoot(eunit EntityUnit) *PickupUnit {
-	chance := rand.IntN(PICKUP_DROP_FREQ) == 0
-	//chance := true
-	for i := range PROJECTILES_MAX {
-		if chance && (nil == c.pickupUnits[i] || !c.pickupUnits[i].active) {
-			kind := rand.IntN(PICKUP_KINDS)
-			//fmt.Println("drop loot ", kind)
-			c.pickupUnits[i] = &PickupUnit{eunit.worldX + PICKUP_DROP_OFFSET,
-				eunit.worldY + PICKUP_DROP_OFFSET, kind, PICKUP_DURATION, true}
-
-			return c.pickupUnits[i]
-
+func (c *Pickup) dropLoot(eunit EntityUnit) []*PickupUnit {
+	// roll the enemy's loot table, a long wait on low health swaps in a health pickup
+	kinds := findLootTable(eunit.kind, c.game.difficulty).roll()
+	if c.pityTicks >= LOOT_PITY_TICKS {
+		hasHealth := false
+		for _, kind := range kinds {
+			hasHealth = hasHealth || kind == PICKUP_HEALTH1 || kind == PICKUP_HEALTH2
+		}
+		if !hasHealth {
+			if len(kinds) >= ENTITY_LOOT_DROP_MAX {
+				kinds = kinds[:ENTITY_LOOT_DROP_MAX-1]
+			}
+			kinds = append(kinds, LOOT_PITY_PICKUP)
 		}
+		c.pityTicks = 0
 	}
-	return nil
+	dropped := []*PickupUnit{}
+	for i, kind := range kinds {
+		// spread side by side about the drop point
+		offsetX := (float64(i) - float64(len(kinds)-1)/2) * PICKUP_DROP_SPREAD
+		punit := c.addPickup(eunit.worldX+PICKUP_DROP_OFFSET+offsetX, eunit.worldY+PICKUP_DROP_OFFSET, kind)
+		if nil != punit {
+			dropped = append(dropped, punit)
+		}
+	}
+	return dropped
 }
 
-func (c *Pickup) checkUnitCollidePlayer(punit *PickupUnit) {
-	if !punit.active {
-		return
+func (c *Pickup) updatePity() {
+	for _, player := range c.game.players {
+		if player.active && player.health*100 <= LOOT_PITY_HEALTH*player.aircraft().health {
+			c.pityTicks += 1
+			return
+		}
 	}
-	collided := Intersect(punit, c.game.player)
-	if collided && c.game.player.active {
-		c.playerTouchPickupAction(punit.kind)
+	c.pityTicks = 0
+}
 
-		punit.active = false
+func (c *Pickup) addBodies(grid *SpatialGrid) {
+	for i, punit := range c.pickupUnits {
+		if nil != punit && punit.active {
+			grid.insert(NewBody(LAYER_PICKUP, i, &punit.active, &punit.Movable, nil))
+		}
+	}
+}
 
-		c.game.incrementScore()
+func (c *Pickup) onHit(self, other *Body) {
+	var punit = c.pickupUnits[self.index]
+	var player = c.game.players[other.index]
+	c.playerTouchPickupAction(punit.kind, player)
 
-	}
+	punit.active = false
 
+	player.addScore(SCORE_PICKUP)
 }
 
-func (c *Pickup) playerTouchPickupAction(kind int) {
-	switch kind {
-	case 0:
-		c.game.player.heal(25)
-	case 1:
-		c.game.player.heal(35)
-	case 2:
-		c.game.player.refuel(25)
-	case 3:
-		c.game.player.refuel(55)
-	default:
-		c.game.player.takeDamage(PROJECTILE_PLAYER_DAMAGE)
-		wx, wy, _, _ := c.game.player.Dimensions()
-		explosionKind := 2
+func (c *Pickup) playerTouchPickupAction(kind int, player *Player) {
+	def := &pickupTable[kind]
+	def.effect(c, player)
+	if def.sound >= 0 {
+		c.game.sound.PlaySFX(def.sound)
+	}
+}
 
-		c.game.explosion.addExplosion(wx, wy, explosionKind)
+func (punit *PickupUnit) bob() float64 {
+	// drawn offset only, the pickup's bounds stay put
+	age := float64(PICKUP_DURATION - punit.life)
+	return PICKUP_BOB_HEIGHT * math.Sin(punit.bobPhase+age*2*math.Pi/PICKUP_BOB_TICKS)
+}
 
+func (punit *PickupUnit) blinkedOut() bool {
+	// true in the off half of the expiry blink
+	if punit.life > PICKUP_BLINK_TICKS {
+		return false
 	}
+	period := 16
+	if punit.life <= PICKUP_BLINK_FAST_TICKS {
+		period = 6
+	}
+	return (punit.life/period)%2 == 1
 }
 
 func (c *Pickup) loopPickups() {
@@ -181,7 +337,11 @@ func (c *Pickup) loopPickups() {
 				if eunit.life > 0 {
 					eunit.life -= 1
 				}
-				c.checkUnitCollidePlayer(eunit)
+				// drift down with the ocean, unless a player is pulling it in
+				if !c.attract(eunit) {
+					eunit.velX, eunit.velY = 0, float64(c.game.background.oceanSpeed)
+					eunit.Motion()
+				}
 			}
 		}
 
@@ -189,9 +349,44 @@ func (c *Pickup) loopPickups() {
 
 }
 
+func (c *Pickup) attractRadius(player *Player) float64 {
+	if player.powerups.active(POWERUP_MAGNET) {
+		return PICKUP_MAGNET_RADIUS
+	}
+	return PICKUP_ATTRACT_RADIUS
+}
+
+func (c *Pickup) attract(punit *PickupUnit) bool {
+	// fly to the closest player in reach, true if one was
+	centerX, centerY := punit.Center()
+	var target *Player
+	bestDistance := math.Inf(1)
+	for _, player := range c.game.players {
+		if !player.active {
+			continue
+		}
+		playerX, playerY := player.Center()
+		distance := math.Hypot(playerX-centerX, playerY-centerY)
+		if distance <= c.attractRadius(player) && distance < bestDistance {
+			target, bestDistance = player, distance
+		}
+	}
+	if nil == target {
+		return false
+	}
+	targetX, targetY := target.Center()
+	// never overshoot, the last step lands on the player
+	speed := math.Min(PICKUP_MAGNET_SPEED, bestDistance)
+	velX, velY := AimVelocity(centerX, centerY, targetX, targetY, speed)
+	punit.worldX += velX
+	punit.worldY += velY
+	return true
+}
+
 func (c *Pickup) Update() error {
 
 	c.loopPickups()
+	c.updatePity()
 	var err error
 	return err
 }
@@ -1,60 +1,165 @@
 package main
 
 import (
-	"bytes"
-	"image"
+	"fmt"
 	"image/color"
 	"log"
+	"math"
 	"path/filepath"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 var (
-	PLAYER_BG_COLOR = color.RGBA{0xff, 0x10, 0x00, 0xff}
+	PLAYER_BG_COLOR          = color.RGBA{0xff, 0x10, 0x00, 0xff}
+	PLAYER_CHARGE_COLOR      = color.RGBA{0x60, 0xd0, 0xff, 0xc0}
+	PLAYER_CHARGE_FULL_COLOR = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	// second player's plane is tinted so the two can be told apart
+	playerTints = [GAME_PLAYERS][3]float32{{1, 1, 1}, {1, 0.75, 0.55}}
+	// banking frames shown over a roll to the right, a roll left mirrors them
+	playerRollFrames = [...]int{3, 4, 0, 1, 2}
 )
 
 const (
 	PLAYER_SIZE                      = 100
-	PLAYER_DEFAULT_SPEED             = 3
 	PLAYER_XMAX                      = WINDOW_WIDTH - PLAYER_SIZE
 	PLAYER_YMAX                      = WINDOW_HEIGHT - PLAYER_SIZE
 	PLAYER_HIT_ENEMY_DAMAGE          = 30
 	PLAYER_COLLIDE_PROJECTILE_DAMAGE = 20
-	PLAYER_RESPAWN_COUNT             = 60
-	HEALTH_MAX                       = 100
-	FUEL_MAX                         = 100
+	PLAYER_CHARGE_MIN_TICKS          = 20
+	PLAYER_CHARGE_MAX_TICKS          = 120
+	PLAYER_CHARGE_RADIUS             = PLAYER_SIZE/2 + 4
+	PLAYER_CHARGE_SEGMENTS           = 32
+	// extra speed while sprinting
+	PLAYER_SPRINT_SPEED = 2
+	// fuel units burned per tick
+	PLAYER_FUEL_BURN        = 0.02
+	PLAYER_FUEL_SPRINT_BURN = 0.06
+	// percent of a full tank
+	PLAYER_FUEL_LOW = 25
+	// percent of full health below which the engine smokes, thicker the lower it gets
+	PLAYER_SMOKE_HEALTH = 50
+	PLAYER_DEBRIS       = 40
+	// empty tanks make the engine cut in and out, then the plane goes down
+	PLAYER_SPUTTER_TICKS    = 20
+	PLAYER_SPUTTER_SPEED    = 0.4
+	PLAYER_FUEL_CRASH_TICKS = 300
+	// a barrel roll slides the plane sideways and can't be hit
+	PLAYER_ROLL_TICKS          = 30
+	PLAYER_ROLL_SPEED          = 7
+	PLAYER_ROLL_COOLDOWN_TICKS = 120
+	// inertia flight, speed gained per tick at full throttle and kept per tick
+	PLAYER_ACCEL = 0.35
+	PLAYER_DRAG  = 0.9
+	// lateral speed, as a fraction of top speed, that shows the first and second banking frames
+	PLAYER_BANK_SLIGHT = 0.25
+	PLAYER_BANK_HARD   = 0.75
 )
 
+// KeyLayout is one player's share of the keyboard
+type KeyLayout struct {
+	up, down, left, right ebiten.Key
+	fire, sprint          ebiten.Key
+	weapon, smartBomb     ebiten.Key
+	bomb, reload          ebiten.Key
+	roll                  ebiten.Key
+}
+
+var playerKeyLayouts = [GAME_PLAYERS]KeyLayout{
+	{ebiten.KeyW, ebiten.KeyS, ebiten.KeyA, ebiten.KeyD,
+		ebiten.KeyF, ebiten.KeyShiftLeft,
+		ebiten.KeyQ, ebiten.KeyX,
+		ebiten.KeyB, ebiten.KeyR,
+		ebiten.KeyE},
+	{ebiten.KeyArrowUp, ebiten.KeyArrowDown, ebiten.KeyArrowLeft, ebiten.KeyArrowRight,
+		ebiten.KeyEnter, ebiten.KeyShiftRight,
+		ebiten.KeyPeriod, ebiten.KeySlash,
+		ebiten.KeyComma, ebiten.KeyQuote,
+		ebiten.KeyBackslash},
+}
+
 type Player struct {
-	game         *Game
-	drawPulser   func() bool
-	images       []*ebiten.Image
-	image        *ebiten.Image
-	respawnCount int
-	speed        int
-	imageID      int
-	sprint       bool
-	active       bool
+	game      *Game
+	id        int
+	keys      KeyLayout
+	scoreRSU  *RasterstringUnit
+	livesRSU  *RasterstringUnit
+	health    int
+	fuel      int
+	lives     int
+	score     int
+	continues int
+	// counts down while the player is out of lives and may still continue
+	continueTicks int
+	// kills in the current chain and ticks left to extend it
+	chain      int
+	chainTicks int
+	// fills with grazes, a full meter turns into a smart bomb
+	grazeMeter int
+	smoke      ParticleEmitter
+	// joined players stay in the game, active ones are flying right now
+	joined      bool
+	drawPulser  func() bool
+	images      []*ebiten.Image
+	hitboxes    []Hitbox
+	image       *ebiten.Image
+	invulnTicks int
+	speed       float64
+	imageID     int
+	sprint      bool
+	// whether the last move was a sprint, lasts until the next update unlike sprint
+	sprinting   bool
+	active      bool
+	fireHeld    bool
+	wasFireHeld bool
+	chargeTicks int
+	fuelBurn    float64
+	emptyTicks  int
+	// ticks left in the current roll and until the next one, -1 or 1 for left or right
+	rollTicks    int
+	rollCooldown int
+	rollDir      float64
 	motionFlags  [4]bool
+	// index into aircraftTable, picked in the hangar
+	aircraftID int
+	// analog stick position, 0 when the stick is centred
+	stickX, stickY float64
+	weapon         *Weapon
+	smartBombs     int
+	powerups       *Powerups
 	Movable
 }
 
-func NewPlayer(g *Game) *Player {
+func NewPlayer(g *Game, id int) *Player {
 	c := &Player{}
 	c.game = g
+	c.id = id
+	c.keys = playerKeyLayouts[id]
 	c.imageID = 2
 	c.image = ebiten.NewImage(PLAYER_SIZE, PLAYER_SIZE)
 	c.image.Fill(PLAYER_BG_COLOR)
 	c.setPositionBottomMiddle()
-	c.speed = PLAYER_DEFAULT_SPEED
+	// the second player starts out on a different plane
+	c.aircraftID = id % len(aircraftTable)
+	c.speed = c.aircraft().speed
 	c.sprint = false
 	c.motionFlags = [...]bool{false, false, false, false}
 	c.width = PLAYER_SIZE
 	c.height = PLAYER_SIZE
-	c.active = true
+	c.active = false
 	c.drawPulser = Pulser(10)
+	c.weapon = NewWeapon(g)
+	c.weapon.player = c
+	c.smartBombs = GAME_START_SMARTBOMBS
+	c.powerups = NewPowerups()
+	c.smoke = NewParticleEmitter(PARTICLE_SMOKE)
+	c.continues = GAME_CONTINUES
+	textX, textY := playerTextX[id], playerTextY[id]
+	c.livesRSU = g.rasterstring.AddRasterStringUnit("", textX, textY)
+	c.scoreRSU = g.rasterstring.AddRasterStringUnit("", textX, textY+GAME_SCORE_Y-GAME_LIVES_Y)
+	c.leave()
 
 	//screenY := (float64)(c.worldY - c.game.screenLocY)
 	//fmt.Println(" player screen y ", screenY)
@@ -62,8 +167,87 @@ func NewPlayer(g *Game) *Player {
 	return c
 }
 
-func (c *Player) Dimensions() (int, int, int, int) {
-	return c.worldX, c.worldY, PLAYER_SIZE, PLAYER_SIZE
+func (c *Player) enterPlay() {
+	// start flying with a full tank and a fresh set of lives
+	c.active = true
+	c.continueTicks = 0
+	c.health = c.aircraft().health
+	c.fuel = c.aircraft().fuel
+	c.fuelBurn, c.emptyTicks = 0, 0
+	c.lives = GAME_START_LIVES
+	c.score = 0
+	c.breakChain()
+	c.grazeMeter = 0
+	c.weapon.reset()
+	c.weapon.kind = c.aircraft().weapon
+	c.smartBombs = GAME_START_SMARTBOMBS
+	if c.aircraft().special == AIRCRAFT_SPECIAL_BOMBS {
+		c.smartBombs = min(SMARTBOMB_MAX, c.smartBombs+AIRCRAFT_EXTRA_BOMBS)
+	}
+	c.powerups.clear()
+	c.game.wingmen.clear(c)
+	c.invulnTicks = c.aircraft().respawnInvuln
+	c.rollTicks, c.rollCooldown = 0, 0
+	c.setPositionBottomMiddle()
+	c.newLifeSpecial()
+	c.updateText()
+}
+
+func (c *Player) join() {
+	c.joined = true
+	c.continues = GAME_CONTINUES
+	c.enterPlay()
+}
+
+func (c *Player) leave() {
+	c.joined = false
+	c.active = false
+	c.continueTicks = 0
+	c.score = 0
+	c.updateText()
+}
+
+func (c *Player) continuesLeft() *int {
+	if GAME_SHARED_CONTINUES {
+		return &c.game.continues
+	}
+	return &c.continues
+}
+
+func (c *Player) pressStart() {
+	// fire drops a new player in, or spends a continue once out of lives
+	switch {
+	case c.active:
+		return
+	case !c.joined:
+		c.join()
+	case c.continueTicks > 0 && *c.continuesLeft() > 0:
+		*c.continuesLeft() -= 1
+		c.enterPlay()
+	default:
+		return
+	}
+	c.game.hud.recalculateBarImages()
+}
+
+func (c *Player) updateText() {
+	switch {
+	case c.active:
+		c.livesRSU.SetText(fmt.Sprintf(playerLivesTS[c.id], c.lives))
+	case !c.joined && c.id > 0:
+		c.livesRSU.SetText(GAME_JOIN_S)
+	case c.continueTicks > 0:
+		seconds := (c.continueTicks + ebiten.DefaultTPS - 1) / ebiten.DefaultTPS
+		c.livesRSU.SetText(fmt.Sprintf(playerContinueTS[c.id], seconds))
+	default:
+		c.livesRSU.SetText("")
+	}
+	c.scoreRSU.SetText(fmt.Sprintf(playerScoreTS[c.id], c.score))
+	c.scoreRSU.visible = c.joined
+}
+
+func (g *Game) onPlayerHit(self, other *Body) {
+	g.players[self.index].takeDamage(other.damage)
 }
 
 func (c *Player) initImagesF() {
@@ -80,139 +264,395 @@ func (c *Player) initImagesF() {
 
 func (c *Player) initImages() {
 
-	img, _, err := image.Decode(bytes.NewReader(AirplanePlayer))
-	if err != nil {
-		log.Fatal(err)
+	c.setAircraft(c.aircraftID)
+	c.image = c.images[1]
+	// the player's hitbox is a circle, no pixel mask to cut
+	c.hitboxes = make([]Hitbox, len(c.images))
+	for i := range c.images {
+		c.hitboxes[i] = NewHitbox(HITBOX_PLAYER, PLAYER_SIZE, PLAYER_SIZE, nil, nil)
+	}
+
+}
+
+func (c *Player) setAircraft(aircraftID int) {
+	// the hangar has the banking frames of every plane ready
+	c.aircraftID = aircraftID
+	c.images = c.game.hangar.images[aircraftID]
+}
+
+func (c *Player) aircraft() *AircraftDef {
+	return &aircraftTable[c.aircraftID]
+}
+
+func (c *Player) newLifeSpecial() {
+	// specials that come with every life
+	switch c.aircraft().special {
+	case AIRCRAFT_SPECIAL_SHIELD:
+		c.powerups.add(POWERUP_SHIELD)
+	case AIRCRAFT_SPECIAL_WINGMAN:
+		c.game.wingmen.add(c)
 	}
-	ebitenImage := ebiten.NewImageFromImage(img)
-	c.images = SpriteCutter(ebitenImage, 100, 100, 5, 1)
-	c.image = SubImage(ebitenImage, 100, 0, 100, 100)
+}
 
+func (c *Player) hitbox() *Hitbox {
+	// the hitbox of the banking frame being shown
+	return &c.hitboxes[c.imageID]
 }
 
 func (c *Player) heal(healthAmount int) {
-	newHealth := c.game.health + healthAmount
-	if newHealth >= 0 && newHealth <= HEALTH_MAX {
-		c.game.health = newHealth
+	newHealth := c.health + healthAmount
+	if newHealth >= 0 && newHealth <= c.aircraft().health {
+		c.health = newHealth
 
 	} else {
-		c.game.health = HEALTH_MAX
+		c.health = c.aircraft().health
 	}
 	c.game.hud.recalculateBarImages()
 }
 
 func (c *Player) refuel(fuelAmount int) {
-	newFuel := c.game.fuel + fuelAmount
-	if newFuel >= 0 && newFuel <= FUEL_MAX {
-		c.game.fuel = newFuel
+	newFuel := c.fuel + fuelAmount
+	if newFuel >= 0 && newFuel <= c.aircraft().fuel {
+		c.fuel = newFuel
 
 	} else {
-		c.game.fuel = FUEL_MAX
+		c.fuel = c.aircraft().fuel
 	}
 	c.game.hud.recalculateBarImages()
 }
 
+func (c *Player) outOfFuel() bool {
+	return c.game.fuelLimited() && c.fuel <= 0
+}
+
+func (c *Player) lowOnFuel() bool {
+	return c.game.fuelLimited() && c.fuel*100 <= PLAYER_FUEL_LOW*c.aircraft().fuel
+}
+
+func (c *Player) sputtering() bool {
+	// the engine cuts out every other sputter period
+	return c.outOfFuel() && (c.emptyTicks/PLAYER_SPUTTER_TICKS)%2 == 1
+}
+
+func (c *Player) updateFuel() {
+	if !c.game.fuelLimited() {
+		c.emptyTicks = 0
+		return
+	}
+	burn := PLAYER_FUEL_BURN
+	if c.sprint && !c.outOfFuel() {
+		burn = PLAYER_FUEL_SPRINT_BURN
+	}
+	c.fuelBurn += burn
+	if c.fuelBurn >= 1 {
+		c.fuelBurn -= 1
+		if c.fuel > 0 {
+			c.fuel -= 1
+			c.game.hud.recalculateBarImages()
+		}
+	}
+	if c.fuel > 0 {
+		c.emptyTicks = 0
+		return
+	}
+	c.emptyTicks += 1
+	if c.emptyTicks%(PLAYER_SPUTTER_TICKS*2) == PLAYER_SPUTTER_TICKS {
+		c.game.sound.PlaySFX(3)
+	}
+	if c.emptyTicks >= PLAYER_FUEL_CRASH_TICKS {
+		c.game.explosion.addExplosion(c.worldX, c.worldY, 1)
+		c.die()
+	}
+}
+
 func (c *Player) fireProjectile() {
-	c.game.projectile.addPlayerProjectile(c.worldX, c.worldY)
+	if c.weapon.fire(c.worldX, c.worldY) {
+		c.game.wingmen.fire(c)
+		c.game.sound.PlaySFX(4)
+	}
+}
+
+func (c *Player) chargeFraction() float64 {
+	// 0 until the fire key has been held long enough to count as charging
+	if c.chargeTicks < PLAYER_CHARGE_MIN_TICKS {
+		return 0
+	}
+	return math.Min(1, float64(c.chargeTicks)/PLAYER_CHARGE_MAX_TICKS)
+}
+
+func (c *Player) updateCharge() {
+	// a tap fires normally, holding charges, releasing fires the charge shot
+	if !weaponTable[c.weapon.kind].charge {
+		if c.fireHeld {
+			c.fireProjectile()
+		}
+		c.chargeTicks = 0
+		c.wasFireHeld = c.fireHeld
+		return
+	}
+	switch {
+	case c.fireHeld && !c.wasFireHeld:
+		c.fireProjectile()
+		c.chargeTicks = 0
+	case c.fireHeld:
+		c.chargeTicks += 1
+	case c.wasFireHeld:
+		if fraction := c.chargeFraction(); fraction > 0 {
+			if c.weapon.fireCharged(c.worldX, c.worldY, fraction) {
+				c.game.sound.PlaySFX(5)
+			}
+		}
+		c.chargeTicks = 0
+	}
+	c.wasFireHeld = c.fireHeld
+}
+
+func (c *Player) drawChargeMeter(screen *ebiten.Image, screenX, screenY float64) {
+	fraction := c.chargeFraction()
+	if fraction <= 0 {
+		return
+	}
+	clr := PLAYER_CHARGE_COLOR
+	if fraction >= 1 {
+		clr = PLAYER_CHARGE_FULL_COLOR
+	}
+	// arc around the ship, clockwise from the nose
+	centerX := screenX + PLAYER_SIZE/2
+	centerY := screenY + PLAYER_SIZE/2
+	segments := int(PLAYER_CHARGE_SEGMENTS * fraction)
+	step := 2 * math.Pi / PLAYER_CHARGE_SEGMENTS
+	for i := range segments {
+		angle0 := -math.Pi/2 + float64(i)*step
+		angle1 := angle0 + step
+		x0 := centerX + PLAYER_CHARGE_RADIUS*math.Cos(angle0)
+		y0 := centerY + PLAYER_CHARGE_RADIUS*math.Sin(angle0)
+		x1 := centerX + PLAYER_CHARGE_RADIUS*math.Cos(angle1)
+		y1 := centerY + PLAYER_CHARGE_RADIUS*math.Sin(angle1)
+		vector.StrokeLine(screen, float32(x0), float32(y0), float32(x1), float32(y1), 3, clr, true)
+	}
+}
+
+func (c *Player) dropBomb() {
+	if c.game.projectile.addPlayerBomb(c) != nil {
+		c.game.sound.PlaySFX(3)
+	}
+}
+
+func (c *Player) addSmartBomb() {
+	c.smartBombs = Clamp(0, SMARTBOMB_MAX, c.smartBombs+1)
 }
 
 func (c *Player) Draw(screen *ebiten.Image) {
+	if !c.active {
+		return
+	}
 	op := &ebiten.DrawImageOptions{}
-	screenX := (float64)(c.worldX - c.game.screenLocX)
-	screenY := (float64)(c.worldY - c.game.screenLocY)
-	_ = screenX
-	_ = screenY
+	screenX, screenY := c.game.WorldToScreen(c.worldX, c.worldY)
 	op.GeoM.Translate(screenX, screenY)
-	if c.game.mode == GAMEOVER || (c.respawnCount > 0 && c.drawPulser()) {
+	tint := playerTints[c.id]
+	op.ColorScale.Scale(tint[0], tint[1], tint[2], 1)
+	c.powerups.tint(op)
+	if c.game.mode == GAMEOVER || (c.invulnTicks > 0 && c.drawPulser()) {
 
 	} else {
 		screen.DrawImage(c.images[c.imageID], op)
 	}
+	c.powerups.Draw(screen, screenX, screenY)
+	c.drawChargeMeter(screen, screenX, screenY)
 
 }
 
 func (c *Player) setPositionBottomMiddle() {
-	//screen coords
-	x := (WINDOW_WIDTH / 2) - (PLAYER_SIZE / 2)
+	//screen coords, the second player starts off to the right
+	x := WINDOW_WIDTH*(2+c.id)/4 - (PLAYER_SIZE / 2)
 	y := (WINDOW_HEIGHT) - PLAYER_SIZE
-	c.worldX = x + c.game.screenLocX
-	c.worldY = y + c.game.screenLocY
+	c.worldX = float64(x + c.game.screenLocX)
+	c.worldY = float64(y + c.game.screenLocY)
+	c.velX, c.velY = 0, 0
 
 }
 
 func (c *Player) Update() error {
 	var err error
 	c.imageID = 0
-	if c.game.mode == PLAY {
+	if c.game.mode == PLAY && c.active {
+		c.updateFuel()
+		c.powerups.Update()
+		c.updateChain()
+		c.updateSmoke()
 		c.setPlayerImage()
 		c.playerMotion()
-		c.checkPlayerCollideEntity()
+		c.weapon.Update()
+		c.updateCharge()
 	}
 
 	c.motionFlags = [...]bool{false, false, false, false}
+	c.stickX, c.stickY = 0, 0
 	c.sprint = false
-	if c.respawnCount > 0 {
-		c.respawnCount -= 1
+	c.fireHeld = false
+	if c.invulnTicks > 0 {
+		c.invulnTicks -= 1
+	}
+	if c.rollTicks > 0 {
+		c.rollTicks -= 1
+	}
+	if c.rollCooldown > 0 {
+		c.rollCooldown -= 1
+	}
+	if c.game.mode == PLAY && c.continueTicks > 0 {
+		c.continueTicks -= 1
+		c.updateText()
+		if c.continueTicks == 0 {
+			c.game.checkGameOver()
+		}
 	}
 	return err
 }
 
-func (c *Player) checkPlayerCollideEntity() {
+func (c *Player) addBodies(grid *SpatialGrid) {
+	if c.active {
+		grid.insert(NewBody(LAYER_PLAYER, c.id, &c.active, &c.Movable, c.hitbox()))
+	}
+}
 
-	for i, entityUnit := range c.game.entity.entityUnits {
-		collided := Intersect(c, &entityUnit)
-		if collided && entityUnit.active {
-			c.game.entity.entityUnits[i].active = false
+func (c *Player) invulnerable() bool {
+	return c.invulnTicks > 0 || c.rolling()
+}
 
-			wx, wy, _, _ := entityUnit.Dimensions()
-			kind := 0
-			if entityUnit.kind > 7 {
-				kind = 1
-			}
-			c.game.explosion.addExplosion(wx, wy, kind)
-			c.game.incrementScore()
-			c.takeDamage(PLAYER_HIT_ENEMY_DAMAGE)
-			//fmt.Println("projectile hit entity")
-			return
-		}
+func (c *Player) rolling() bool {
+	return c.rollTicks > 0
+}
 
+func (c *Player) startRoll() {
+	// roll the way the player is steering, or towards the middle of the screen
+	if c.rolling() || c.rollCooldown > 0 {
+		return
+	}
+	screenX, _ := c.game.WorldToScreen(c.worldX, c.worldY)
+	switch {
+	case c.motionFlags[2]:
+		c.rollDir = -1
+	case c.motionFlags[3]:
+		c.rollDir = 1
+	case screenX > PLAYER_XMAX/2:
+		c.rollDir = -1
+	default:
+		c.rollDir = 1
 	}
+	c.rollTicks = PLAYER_ROLL_TICKS
+	c.rollCooldown = PLAYER_ROLL_TICKS + PLAYER_ROLL_COOLDOWN_TICKS
+	if c.aircraft().special == AIRCRAFT_SPECIAL_ROLL {
+		c.rollCooldown = PLAYER_ROLL_TICKS + PLAYER_ROLL_COOLDOWN_TICKS/2
+	}
+	c.game.sound.PlaySFX(3)
+}
 
+func (c *Player) rollReady() float64 {
+	// 1 once another roll can start
+	return 1 - float64(c.rollCooldown)/(PLAYER_ROLL_TICKS+PLAYER_ROLL_COOLDOWN_TICKS)
 }
 
 func (c *Player) takeDamage(damageAmount int) {
-	newHealth := c.game.health - damageAmount
+	// every source of damage goes through here, so this covers them all
+	if c.invulnerable() {
+		return
+	}
+	c.invulnTicks = c.aircraft().hitInvuln
+	c.breakChain()
+	if c.powerups.absorbHit() {
+		c.game.sound.PlaySFX(6)
+		return
+	}
+	newHealth := c.health - damageAmount
 	if newHealth > 0 {
-		c.game.health = newHealth
+		c.health = newHealth
 	} else {
-		c.game.health = 0
+		c.health = 0
 		c.die()
 	}
 	c.game.hud.recalculateBarImages()
 
 }
 
+func (c *Player) updateSmoke() {
+	// trails behind the plane, from a wisp at PLAYER_SMOKE_HEALTH to thick at none
+	health := 100 * c.health / c.aircraft().health
+	if health >= PLAYER_SMOKE_HEALTH {
+		return
+	}
+	centerX, centerY := c.Center()
+	scale := 1 + 3*float64(PLAYER_SMOKE_HEALTH-health)/PLAYER_SMOKE_HEALTH
+	c.game.particles.emit(&c.smoke, centerX, centerY+PLAYER_SIZE/4, math.Pi/2, scale)
+}
+
 func (c *Player) die() {
-	c.respawnCount = PLAYER_RESPAWN_COUNT
-	c.game.decrementLives()
+	centerX, centerY := c.Center()
+	c.game.particles.burst(PARTICLE_DEBRIS, centerX, centerY, PLAYER_DEBRIS)
+	c.invulnTicks = c.aircraft().respawnInvuln
+	c.lives -= 1
 	c.setPositionBottomMiddle()
-	c.game.health = GAME_START_HEALTH
-	c.game.fuel = GAME_START_FUEL
-	if c.game.lives < 0 {
-		c.game.mode = GAMEOVER
-		c.game.setStatusStringToMode()
-		c.game.middleRSU.visible = true
+	c.health = c.aircraft().health
+	c.fuel = c.aircraft().fuel
+	c.fuelBurn, c.emptyTicks = 0, 0
+	c.weapon.refill()
+	c.powerups.clear()
+	c.game.wingmen.clear(c)
+	c.rollTicks = 0
+	if c.lives >= 0 {
+		c.newLifeSpecial()
+	}
+	if c.lives < 0 {
+		// out of lives, sits out until a continue or the next game
+		c.active = false
+		if *c.continuesLeft() > 0 {
+			c.continueTicks = GAME_CONTINUE_TICKS
+		}
+		c.game.checkGameOver()
 	}
+	c.updateText()
 
 }
 
 func (c *Player) playerMotion() {
-	c.velX, c.velY = 0, 0
-	if c.sprint {
-		c.speed = PLAYER_DEFAULT_SPEED + 2
+	c.sprinting = c.sprint && !c.outOfFuel()
+	if c.sprinting {
+		c.speed = c.aircraft().speed + PLAYER_SPRINT_SPEED
+	} else {
+		c.speed = c.aircraft().speed
+	}
+	if c.powerups.active(POWERUP_SPEED) {
+		c.speed *= POWERUP_SPEED_FACTOR
+	}
+	if c.sputtering() {
+		c.speed *= PLAYER_SPUTTER_SPEED
+	}
+	if c.game.inertia {
+		c.inertiaMotion()
 	} else {
-		c.speed = PLAYER_DEFAULT_SPEED
+		c.directMotion()
 	}
+	if c.rolling() {
+		c.velX = c.rollDir * PLAYER_ROLL_SPEED
+		if c.game.inertia {
+			c.maxSpeed = math.Hypot(PLAYER_ROLL_SPEED, c.speed)
+		}
+	}
+
+	c.Motion()
+	// hitting the edge of the screen kills the speed into it
+	if clamped := Clamp(0, PLAYER_XMAX, c.worldX); clamped != c.worldX {
+		c.worldX, c.velX = clamped, 0
+	}
+	if clamped := Clamp(0, PLAYER_YMAX, c.worldY); clamped != c.worldY {
+		c.worldY, c.velY = clamped, 0
+	}
+}
+
+func (c *Player) directMotion() {
+	// full speed the moment a key is down, stopped the moment it is up
+	c.velX, c.velY = 0, 0
+	c.accX, c.accY = 0, 0
+	c.maxSpeed = 0
 	if c.motionFlags[0] {
 		c.velY = -c.speed
 	}
@@ -225,15 +665,65 @@ func (c *Player) playerMotion() {
 	if c.motionFlags[3] {
 		c.velX = c.speed
 	}
+}
+
+func (c *Player) throttle() (float64, float64) {
+	// stick position if it is pushed, otherwise full throttle on each key held
+	if c.stickX != 0 || c.stickY != 0 {
+		return c.stickX, c.stickY
+	}
+	throttleX, throttleY := 0.0, 0.0
+	if c.motionFlags[0] {
+		throttleY -= 1
+	}
+	if c.motionFlags[1] {
+		throttleY += 1
+	}
+	if c.motionFlags[2] {
+		throttleX -= 1
+	}
+	if c.motionFlags[3] {
+		throttleX += 1
+	}
+	return throttleX, throttleY
+}
 
-	var propWorldX = c.worldX + c.velX
-	var propWorldY = c.worldY + c.velY
-	c.worldX = Clamp(0, PLAYER_XMAX, propWorldX)
-	c.worldY = Clamp(0, PLAYER_YMAX, propWorldY)
+func (c *Player) inertiaMotion() {
+	// accelerate with the throttle, drag slows the plane once it is let go
+	throttleX, throttleY := c.throttle()
+	// diagonal movement is no faster than straight
+	c.maxSpeed = c.speed
+	if magnitude := math.Hypot(throttleX, throttleY); magnitude > 1 {
+		throttleX, throttleY = throttleX/magnitude, throttleY/magnitude
+	}
+	c.accX = throttleX * PLAYER_ACCEL * c.speed / c.aircraft().speed
+	c.accY = throttleY * PLAYER_ACCEL * c.speed / c.aircraft().speed
+	if throttleX == 0 {
+		c.velX *= PLAYER_DRAG
+	}
+	if throttleY == 0 {
+		c.velY *= PLAYER_DRAG
+	}
+	// top speed follows the stick so a half push cruises at half speed
+	if throttleX != 0 || throttleY != 0 {
+		c.maxSpeed = c.speed * math.Min(1, math.Hypot(throttleX, throttleY))
+	}
 }
 
 func (c *Player) setPlayerImage() {
 	c.imageID = 2
+	if c.game.inertia && !c.rolling() {
+		c.bankImage()
+		return
+	}
+	if c.rolling() {
+		step := (PLAYER_ROLL_TICKS - c.rollTicks) * len(playerRollFrames) / PLAYER_ROLL_TICKS
+		c.imageID = playerRollFrames[min(step, len(playerRollFrames)-1)]
+		if c.rollDir < 0 {
+			c.imageID = len(c.images) - 1 - c.imageID
+		}
+		return
+	}
 
 	if c.motionFlags[2] {
 		c.imageID = 1
@@ -249,3 +739,18 @@ func (c *Player) setPlayerImage() {
 	}
 
 }
+
+func (c *Player) bankImage() {
+	// bank with the sideways speed rather than the keys held
+	bank := c.velX / math.Max(1, c.speed)
+	switch {
+	case bank <= -PLAYER_BANK_HARD:
+		c.imageID = 0
+	case bank <= -PLAYER_BANK_SLIGHT:
+		c.imageID = 1
+	case bank >= PLAYER_BANK_HARD:
+		c.imageID = 4
+	case bank >= PLAYER_BANK_SLIGHT:
+		c.imageID = 3
+	}
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	POWERUP_SHIELD = iota
+	POWERUP_SPEED
+	POWERUP_RAPID
+	POWERUP_DOUBLE
+	POWERUP_MAGNET
+	POWERUP_KINDS
+)
+
+const (
+	POWERUP_STACK_REFRESH = iota
+	POWERUP_STACK_EXTEND
+	POWERUP_STACK_IGNORE
+)
+
+const (
+	POWERUP_SPEED_FACTOR    = 1.5
+	POWERUP_RAPID_FACTOR    = 0.5
+	POWERUP_DAMAGE_FACTOR   = 2
+	POWERUP_SHIELD_RADIUS   = PLAYER_SIZE/2 - 2
+	POWERUP_SHIELD_SEGMENTS = 24
+	// power-ups blink on the ship and in the hud for their last ticks
+	POWERUP_EXPIRE_TICKS = 120
+)
+
+var (
+	powerupNames    = [POWERUP_KINDS]string{"SHIELD", "SPEED", "RAPID", "DOUBLE", "MAGNET"}
+	powerupStacking = map[string]int{
+		"refresh": POWERUP_STACK_REFRESH,
+		"extend":  POWERUP_STACK_EXTEND,
+		"ignore":  POWERUP_STACK_IGNORE,
+	}
+	// cells of icons2.png, shared by the pickups and the hud
+	// the pickup that starts each power-up, the HUD shows its icon
+	powerupPickups = [POWERUP_KINDS]int{PICKUP_SHIELD, PICKUP_SPEED, PICKUP_RAPID, PICKUP_DOUBLE, PICKUP_MAGNET}
+	shieldColor    = color.RGBA{0x60, 0xd0, 0xff, 0xa0}
+	speedColor     = color.RGBA{0xa0, 0xff, 0xf0, 0x80}
+	rapidColor     = color.RGBA{0xff, 0xe0, 0x40, 0xc0}
+	powerupTable   = loadPowerupTable(PowerupsCfg)
+)
+
+// PowerupDef is one line of data/powerups.cfg
+type PowerupDef struct {
+	ticks, maxTicks int
+	stacking        int
+	hits, maxHits   int
+}
+
+// Powerups are the timed states one player has running
+type Powerups struct {
+	ticks      [POWERUP_KINDS]int
+	shieldHits int
+	pulser     func() bool
+	// off half of the expiry blink, advanced once per tick
+	blink bool
+}
+
+func loadPowerupTable(data []byte) [POWERUP_KINDS]PowerupDef {
+	table := [POWERUP_KINDS]PowerupDef{}
+	for _, fields := range getConfigRecords(data) {
+		if len(fields) < 6 {
+			log.Fatalf("powerups.cfg: short line %v", fields)
+		}
+		kind := -1
+		for i, name := range powerupNames {
+			if name == fields[0] {
+				kind = i
+			}
+		}
+		stacking, ok := powerupStacking[fields[2]]
+		if kind < 0 || !ok {
+			log.Fatalf("powerups.cfg: bad line %v", fields)
+		}
+		numbers := [4]int{}
+		for i, field := range []string{fields[1], fields[3], fields[4], fields[5]} {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				log.Fatal(err)
+			}
+			numbers[i] = n
+		}
+		table[kind] = PowerupDef{numbers[0], numbers[1], stacking, numbers[2], numbers[3]}
+	}
+	return table
+}
+
+func powerupIcons(rasterstring *Rasterstring, width, height int) [POWERUP_KINDS]*ebiten.Image {
+	img, _, err := image.Decode(bytes.NewReader(Icons2Png))
+	if err != nil {
+		log.Fatal(err)
+	}
+	ebitenImage := ebiten.NewImageFromImage(img)
+	icons := [POWERUP_KINDS]*ebiten.Image{}
+	for kind, pickup := range powerupPickups {
+		icons[kind] = pickupIcon(ebitenImage, rasterstring, &pickupTable[pickup], width, height)
+	}
+	return icons
+}
+
+func NewPowerups() *Powerups {
+	c := &Powerups{}
+	c.pulser = Pulser(8)
+	return c
+}
+
+func (c *Powerups) clear() {
+	c.ticks = [POWERUP_KINDS]int{}
+	c.shieldHits = 0
+}
+
+func (c *Powerups) active(kind int) bool {
+	return c.ticks[kind] > 0
+}
+
+func (c *Powerups) fraction(kind int) float64 {
+	// time left as a fraction of the longest it can run
+	return float64(c.ticks[kind]) / float64(max(1, powerupTable[kind].maxTicks))
+}
+
+func (c *Powerups) expiring(kind int) bool {
+	return c.active(kind) && c.ticks[kind] <= POWERUP_EXPIRE_TICKS
+}
+
+func (c *Powerups) add(kind int) {
+	def := &powerupTable[kind]
+	switch {
+	case !c.active(kind):
+		c.ticks[kind] = def.ticks
+	case def.stacking == POWERUP_STACK_REFRESH:
+		c.ticks[kind] = max(c.ticks[kind], def.ticks)
+	case def.stacking == POWERUP_STACK_EXTEND:
+		c.ticks[kind] = min(def.maxTicks, c.ticks[kind]+def.ticks)
+	case def.stacking == POWERUP_STACK_IGNORE:
+		return
+	}
+	if kind == POWERUP_SHIELD {
+		c.shieldHits = min(def.maxHits, c.shieldHits+def.hits)
+	}
+}
+
+func (c *Powerups) absorbHit() bool {
+	// true if the shield took the hit, it pops once out of hits
+	if !c.active(POWERUP_SHIELD) || c.shieldHits <= 0 {
+		return false
+	}
+	c.shieldHits -= 1
+	if c.shieldHits == 0 {
+		c.ticks[POWERUP_SHIELD] = 0
+	}
+	return true
+}
+
+func (c *Powerups) Update() {
+	for kind := range c.ticks {
+		if c.ticks[kind] > 0 {
+			c.ticks[kind] -= 1
+		}
+	}
+	if !c.active(POWERUP_SHIELD) {
+		c.shieldHits = 0
+	}
+	c.blink = c.pulser()
+}
+
+func (c *Powerups) visible(kind int) bool {
+	return c.active(kind) && !(c.expiring(kind) && c.blink)
+}
+
+func (c *Powerups) tint(op *ebiten.DrawImageOptions) {
+	// double damage glows red
+	if c.visible(POWERUP_DOUBLE) {
+		op.ColorScale.Scale(1, 0.55, 0.55, 1)
+	}
+}
+
+func (c *Powerups) Draw(screen *ebiten.Image, screenX, screenY float64) {
+	centerX := float32(screenX + PLAYER_SIZE/2)
+	centerY := float32(screenY + PLAYER_SIZE/2)
+	if c.visible(POWERUP_SPEED) {
+		// streaks trailing behind the wings
+		for _, offsetX := range []float32{-30, -10, 10, 30} {
+			x := centerX + offsetX
+			vector.StrokeLine(screen, x, centerY+20, x, centerY+50, 2, speedColor, true)
+		}
+	}
+	if c.visible(POWERUP_RAPID) {
+		vector.DrawFilledCircle(screen, centerX, float32(screenY+4), 5, rapidColor, true)
+	}
+	if c.visible(POWERUP_SHIELD) {
+		// the bubble thickens with every hit it has left
+		width := float32(1 + c.shieldHits)
+		vector.StrokeCircle(screen, centerX, centerY, POWERUP_SHIELD_RADIUS, width, shieldColor, true)
+		step := 2 * math.Pi / POWERUP_SHIELD_SEGMENTS
+		for i := 0; i < POWERUP_SHIELD_SEGMENTS; i += 2 {
+			angle := float64(i) * step
+			x := centerX + float32(POWERUP_SHIELD_RADIUS*math.Cos(angle))
+			y := centerY + float32(POWERUP_SHIELD_RADIUS*math.Sin(angle))
+			vector.DrawFilledCircle(screen, x, y, 1.5, PLAYER_CHARGE_FULL_COLOR, true)
+		}
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"image"
 	"image/color"
 	"log"
+	"math"
 	"path/filepath"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
 )
 
 const (
@@ -17,50 +19,84 @@ const (
 	PROJECTILE_H             = 20
 	PROJECTILE_W             = 8
 	PROJECTILES_MAX          = 10
+	PROJECTILES_P_MAX        = 40
 	PROJECTILE_OFFSET_X      = 50
 	PROJECTILE_OFFSET_Y      = 1
 	PROJECTILE_BORDER        = 100
 	PROJECTILE_MIN_INTERVAL  = 500
 	PROJECTILE_PLAYER_DAMAGE = 25
+	PROJECTILE_BOMBS_MAX     = 3
+	PROJECTILE_BOMB_SIZE     = 16
+	PROJECTILE_BOMB_FUSE     = 45
+	PROJECTILE_BOMB_INTERVAL = 1000
+	PROJECTILE_BOMB_BLAST    = 80
+	PROJECTILE_HOMING_TURN   = 0.06
+	// enemy shots passing this close outside the player's hitbox graze it
+	PROJECTILE_GRAZE_MARGIN = 16
+	PROJECTILE_SPARKS       = 6
 )
 
 var (
 	projectileColorE = color.RGBA{0xff, 0xff, 0x30, 0xff}
 	projectileColorP = color.RGBA{0xe0, 0xe0, 0x6f, 0xff}
+	projectileColorB = color.RGBA{0x20, 0x20, 0x20, 0xff}
+	projectileColorG = color.RGBA{0xff, 0xe0, 0x40, 0xff}
+	projectileColorS = color.RGBA{0xff, 0x90, 0x20, 0xff}
+	projectileColorL = color.RGBA{0x60, 0xf0, 0xff, 0xff}
+	projectileColorC = color.RGBA{0x80, 0xd8, 0xff, 0xc0}
 )
 
 type Projectile struct {
 	game             *Game
 	imageP, imageE   *ebiten.Image
-	projectileUnitsP [PROJECTILES_MAX]ProjectileUnit
+	imageB           *ebiten.Image
+	imagesW          [WEAPON_KINDS]*ebiten.Image
+	imageC           *ebiten.Image
+	projectileUnitsP [PROJECTILES_P_MAX]ProjectileUnit
 	projectileUnitsE [PROJECTILES_MAX]ProjectileUnit
-	lastTimeMilli    int64
-	testRect         Movable
+	projectileUnitsB [PROJECTILE_BOMBS_MAX]ProjectileUnit
+	// bomb blasts, each is in one collision pass and gone the next tick
+	blastUnits    [PROJECTILE_BOMBS_MAX]ProjectileUnit
+	lastTimeMilli int64
+	lastBombMilli [GAME_PLAYERS]int64
+	testRect      Movable
 }
 
 /*
 kinds:
-0 = player
-1 = enemy
+0 = enemy
+1 = player
+2 = player bomb
+3 = player charge shot
 */
 const (
 	PROJ_E = iota
 	PROJ_P
+	PROJ_B
+	PROJ_C
 )
 
 type ProjectileUnit struct {
-	worldX, worldY, kind int
-	velX, velY           int
-	active               bool
-}
-
-func (punit *ProjectileUnit) Motion() {
-	punit.worldX += punit.velX
-	punit.worldY += punit.velY
-}
-
-func (punit *ProjectileUnit) Dimensions() (int, int, int, int) {
-	return punit.worldX, punit.worldY, PROJECTILE_W, PROJECTILE_H
+	kind           int
+	weapon, damage int
+	fuse           int
+	// entity slots already hit by a piercing shot
+	hitMask uint64
+	// homing missiles steer toward the entity slot in target, -1 for none
+	target  int
+	heading float64
+	// player who fired it, gets the credit for hits
+	owner *Player
+	// position before the last move, hits are swept from here
+	lastX, lastY float64
+	active       bool
+	pierce       bool
+	homing       bool
+	// enemy shots only graze a player once
+	grazed bool
+	// rockets and missiles leave a trail
+	exhaust ParticleEmitter
+	Movable
 }
 
 func NewProjectile(g *Game) *Projectile {
@@ -68,9 +104,12 @@ func NewProjectile(g *Game) *Projectile {
 	c.game = g
 	c.lastTimeMilli = time.Now().UnixMilli()
 	c.projectileUnitsE = [PROJECTILES_MAX]ProjectileUnit{}
-	c.projectileUnitsP = [PROJECTILES_MAX]ProjectileUnit{}
+	c.projectileUnitsP = [PROJECTILES_P_MAX]ProjectileUnit{}
+	c.projectileUnitsB = [PROJECTILE_BOMBS_MAX]ProjectileUnit{}
 	c.initImages()
-	c.testRect = Movable{0, 0, 0, 0, PROJECTILE_W, PROJECTILE_H}
+	c.testRect = Movable{width: PROJECTILE_W, height: PROJECTILE_H}
+	g.grid.handle(LAYER_PLAYER_SHOT, c.onPlayerShotHit)
+	g.grid.handle(LAYER_ENEMY_SHOT, c.onEnemyShotHit)
 	//c.projectileUnitsE[0] = ProjectileUnit{200, 200, 1, 0, 3, true}
 	return c
 }
@@ -109,28 +148,66 @@ func (c *Projectile) initImages() {
 	ebitenImage = ebiten.NewImageFromImage(img)
 	c.imageE = ScaleImage(ebitenImage, PROJECTILE_W, PROJECTILE_H)
 
+	c.imageB = ebiten.NewImage(PROJECTILE_BOMB_SIZE, PROJECTILE_BOMB_SIZE)
+	half := float32(PROJECTILE_BOMB_SIZE) / 2
+	vector.DrawFilledCircle(c.imageB, half, half, half, projectileColorB, true)
+
+	// player weapon sprites, stretched to the size of each shot when drawn
+	c.imagesW[WEAPON_MACHINEGUN] = ebiten.NewImage(4, 10)
+	c.imagesW[WEAPON_MACHINEGUN].Fill(projectileColorG)
+	c.imagesW[WEAPON_TWIN_ROCKETS] = c.imageP
+	c.imagesW[WEAPON_SPREAD] = ebiten.NewImage(8, 8)
+	vector.DrawFilledCircle(c.imagesW[WEAPON_SPREAD], 4, 4, 4, projectileColorS, true)
+	c.imagesW[WEAPON_HOMING] = c.imageE
+	c.imagesW[WEAPON_LASER] = ebiten.NewImage(4, 40)
+	c.imagesW[WEAPON_LASER].Fill(projectileColorL)
+
+	// charge shot, a glowing ball stretched to the charge size
+	c.imageC = ebiten.NewImage(32, 32)
+	vector.DrawFilledCircle(c.imageC, 16, 16, 16, projectileColorC, true)
+	vector.DrawFilledCircle(c.imageC, 16, 16, 9, color.White, true)
+
 }
 
 func (c *Projectile) Draw(screen *ebiten.Image) {
-	for i := range PROJECTILES_MAX {
+	for i := range PROJECTILES_P_MAX {
 		// player
-		var projectile = c.projectileUnitsP[i]
+		var projectile = &c.projectileUnitsP[i]
 		if projectile.active {
 			screenX, screenY := c.game.WorldToScreen(projectile.worldX, projectile.worldY)
 
-			c.drawProjectile(screen, screenX, screenY, PROJ_P)
+			c.drawPlayerProjectile(screen, screenX, screenY, projectile)
 		}
+	}
+	for i := range PROJECTILES_MAX {
 		// enemy
-		projectile = c.projectileUnitsE[i]
+		var projectile = c.projectileUnitsE[i]
 		if projectile.active {
 			screenX, screenY := c.game.WorldToScreen(projectile.worldX, projectile.worldY)
 			c.drawProjectile(screen, screenX, screenY, PROJ_E)
 		}
 	}
+	for i := range PROJECTILE_BOMBS_MAX {
+		var bomb = c.projectileUnitsB[i]
+		if bomb.active {
+			screenX, screenY := c.game.WorldToScreen(bomb.worldX, bomb.worldY)
+			c.drawBomb(screen, screenX, screenY, bomb.fuse)
+		}
+	}
 
 }
 
-func (c *Projectile) drawProjectile(screen *ebiten.Image, screenX, screenY, kind int) {
+func (c *Projectile) drawBomb(screen *ebiten.Image, screenX, screenY float64, fuse int) {
+	// bombs shrink as they fall toward the ocean
+	scale := 0.5 + 0.5*float64(fuse)/PROJECTILE_BOMB_FUSE
+	offset := PROJECTILE_BOMB_SIZE * (1 - scale) / 2
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scale, scale)
+	op.GeoM.Translate(screenX+offset, screenY+offset)
+	screen.DrawImage(c.imageB, op)
+}
+
+func (c *Projectile) drawProjectile(screen *ebiten.Image, screenX, screenY float64, kind int) {
 	var image *ebiten.Image
 	if kind == PROJ_P {
 		image = c.imageP
@@ -138,11 +215,45 @@ func (c *Projectile) drawProjectile(screen *ebiten.Image, screenX, screenY, kind
 		image = c.imageE
 	}
 	op := &ebiten.DrawImageOptions{}
-	op.GeoM.Translate((float64)(screenX), (float64)(screenY))
+	op.GeoM.Translate(screenX, screenY)
 	screen.DrawImage(image, op)
 
 }
 
+func (c *Projectile) drawPlayerProjectile(screen *ebiten.Image, screenX, screenY float64, punit *ProjectileUnit) {
+	if punit.homing {
+		c.drawHoming(screen, screenX, screenY, punit)
+		return
+	}
+	var image = c.imagesW[punit.weapon]
+	if punit.kind == PROJ_C {
+		image = c.imageC
+	}
+	scaleX := float64(punit.width) / float64(image.Bounds().Dx())
+	scaleY := float64(punit.height) / float64(image.Bounds().Dy())
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Scale(scaleX, scaleY)
+	op.GeoM.Translate(screenX, screenY)
+	screen.DrawImage(image, op)
+
+}
+
+func (c *Projectile) drawHoming(screen *ebiten.Image, screenX, screenY float64, punit *ProjectileUnit) {
+	// red once locked on, yellow while searching for a target
+	var image = c.imageE
+	if punit.target >= 0 {
+		image = c.imageP
+	}
+	halfW := float64(image.Bounds().Dx()) / 2
+	halfH := float64(image.Bounds().Dy()) / 2
+	op := &ebiten.DrawImageOptions{}
+	op.GeoM.Translate(-halfW, -halfH)
+	// sprites point up, heading 0 points right
+	op.GeoM.Rotate(punit.heading + math.Pi/2)
+	op.GeoM.Translate(screenX+float64(punit.width)/2, screenY+float64(punit.height)/2)
+	screen.DrawImage(image, op)
+}
+
 func (c *Projectile) projectileInBounds(punit *ProjectileUnit) bool {
 	// true if unit in bounds
 	if punit.worldX < -PROJECTILE_BORDER ||
@@ -155,67 +266,47 @@ func (c *Projectile) projectileInBounds(punit *ProjectileUnit) bool {
 	}
 }
 
-func (c *Projectile) addProjectile0(worldX, worldY, kind int) *ProjectileUnit {
-	var puArray = &[PROJECTILES_MAX]ProjectileUnit{}
-	var velX, velY = 0, 0
-	var worldXC, worldYC = worldX, worldY
-	_ = velX
-	_ = velY
-	if kind == PROJ_P {
-		velY = -PROJECTILE_SPEED
-		velX = 0
-		worldXC += PROJECTILE_OFFSET_X
-		worldYC += PROJECTILE_OFFSET_Y
-		puArray = &c.projectileUnitsP
-	} else {
-		velY = PROJECTILE_SPEED
-		velX = 0
-		puArray = &c.projectileUnitsE
-	}
-	var nowMilli = time.Now().UnixMilli()
-	for i := range PROJECTILES_MAX {
-		var limitReached = (nowMilli-c.lastTimeMilli > PROJECTILE_MIN_INTERVAL)
-		if !puArray[i].active && limitReached {
-			puArray[i] = ProjectileUnit{worldXC, worldYC, kind, velX, velY, true}
-			//fmt.Println("add projectil ", i)
-			c.lastTimeMilli = nowMilli
-			return &puArray[i]
-			//puArray[i] = ProjectileUnit{worldX, worldY, kind, velX, velY, true}
-
+func (c *Projectile) addPlayerProjectile(worldX, worldY, velX, velY float64, weapon int) *ProjectileUnit {
+	// fire rate is limited by the weapon cooldown, not here
+	def := &weaponTable[weapon]
+	for i := range PROJECTILES_P_MAX {
+		if !c.projectileUnitsP[i].active {
+			c.projectileUnitsP[i] = ProjectileUnit{kind: PROJ_P, weapon: weapon, damage: def.damage, pierce: def.pierce,
+				homing: def.homing, target: -1, active: true,
+				Movable: Movable{worldX: worldX, worldY: worldY, velX: velX, velY: velY,
+					maxSpeed: def.speed, width: def.width, height: def.height}}
+			var punit = &c.projectileUnitsP[i]
+			if weapon == WEAPON_TWIN_ROCKETS || weapon == WEAPON_HOMING {
+				punit.exhaust = NewParticleEmitter(PARTICLE_EXHAUST)
+			}
+			if punit.homing {
+				punit.heading = math.Atan2(velY, velX)
+				// only lock on to targets ahead of the player
+				punit.target = c.acquireTarget(worldX, worldY, 0, -1)
+			}
+			return punit
 		}
 	}
 	return nil
 }
 
-func (c *Projectile) addPlayerProjectile(worldX, worldY int) *ProjectileUnit {
-	var puArray = &[PROJECTILES_MAX]ProjectileUnit{}
-	var velX, velY = 0, 0
-	var worldXC, worldYC = worldX, worldY
-
-	velY = -PROJECTILE_SPEED
-	velX = 0
-	worldXC += PROJECTILE_OFFSET_X
-	worldYC += PROJECTILE_OFFSET_Y
-	puArray = &c.projectileUnitsP
-
-	var nowMilli = time.Now().UnixMilli()
-	for i := range PROJECTILES_MAX {
-		var limitReached = (nowMilli-c.lastTimeMilli > PROJECTILE_MIN_INTERVAL)
-		if !puArray[i].active && limitReached {
-			puArray[i] = ProjectileUnit{worldXC, worldYC, PROJ_P, velX, velY, true}
-			//fmt.Println("add projectil ", i)
-			c.lastTimeMilli = nowMilli
-			return &puArray[i]
-			//puArray[i] = ProjectileUnit{worldX, worldY, kind, velX, velY, true}
-
+func (c *Projectile) addChargedProjectile(worldX, worldY float64, size, damage int, owner *Player) *ProjectileUnit {
+	// a piercing ball straight up, whatever weapon charged it
+	for i := range PROJECTILES_P_MAX {
+		if !c.projectileUnitsP[i].active {
+			c.projectileUnitsP[i] = ProjectileUnit{kind: PROJ_C, damage: damage, pierce: true, target: -1,
+				owner: owner, active: true,
+				Movable: Movable{worldX: worldX, worldY: worldY, velY: -WEAPON_CHARGE_SPEED,
+					maxSpeed: WEAPON_CHARGE_SPEED, width: size, height: size}}
+			return &c.projectileUnitsP[i]
 		}
 	}
 	return nil
 }
 
-func (c *Projectile) addEnemyProjectile(worldX, worldY int) *ProjectileUnit {
+func (c *Projectile) addEnemyProjectile(worldX, worldY float64) *ProjectileUnit {
 	//var puArray = &[PROJECTILES_MAX]ProjectileUnit{}
-	var velX, velY = 0, 0
+	var velX, velY = 0.0, 0.0
 	var worldXC, worldYC = worldX, worldY
 
 	velY = PROJECTILE_SPEED
@@ -224,7 +315,9 @@ func (c *Projectile) addEnemyProjectile(worldX, worldY int) *ProjectileUnit {
 	//puArray = &c.projectileUnitsE
 	for i := range PROJECTILES_MAX {
 		if nil == &c.projectileUnitsE[i] || !c.projectileUnitsE[i].active {
-			c.projectileUnitsE[i] = ProjectileUnit{worldXC, worldYC, kind, velX, velY, true}
+			c.projectileUnitsE[i] = ProjectileUnit{kind: kind, damage: PROJECTILE_PLAYER_DAMAGE, active: true,
+				Movable: Movable{worldX: worldXC, worldY: worldYC, velX: velX, velY: velY,
+					width: PROJECTILE_W, height: PROJECTILE_H}}
 			//fmt.Println("add projectil ", i)
 			return &c.projectileUnitsE[i]
 
@@ -233,76 +326,216 @@ func (c *Projectile) addEnemyProjectile(worldX, worldY int) *ProjectileUnit {
 	return nil
 }
 
-func (c *Projectile) checkUnitCollideEntity(punit *ProjectileUnit) int {
-	if !punit.active {
-		return -1
+func (c *Projectile) addPlayerBomb(owner *Player) *ProjectileUnit {
+	var nowMilli = time.Now().UnixMilli()
+	if nowMilli-c.lastBombMilli[owner.id] < PROJECTILE_BOMB_INTERVAL {
+		return nil
 	}
-	for i, entityUnit := range c.game.entity.entityUnits {
-		collided := Intersect(punit, &entityUnit)
-		if collided && entityUnit.active {
-			c.game.entity.entityUnits[i].active = false
-			c.game.pickup.dropLoot(entityUnit)
-			punit.active = false
-			wx, wy, _, _ := entityUnit.Dimensions()
-			explosionKind := 0
-			if entityUnit.kind > 7 {
-				explosionKind = 1
-			}
-			c.game.explosion.addExplosion(wx, wy, explosionKind)
-			c.game.incrementScore()
-			//fmt.Println("projectile hit entity")
-			return i
+	// bombs fall onto the ocean and scroll with it until the fuse runs out
+	worldXC := owner.worldX + PLAYER_SIZE/2 - PROJECTILE_BOMB_SIZE/2
+	worldYC := owner.worldY + PLAYER_SIZE/2 - PROJECTILE_BOMB_SIZE/2
+	for i := range PROJECTILE_BOMBS_MAX {
+		if !c.projectileUnitsB[i].active {
+			c.projectileUnitsB[i] = ProjectileUnit{kind: PROJ_B, fuse: PROJECTILE_BOMB_FUSE, active: true, owner: owner,
+				Movable: Movable{worldX: worldXC, worldY: worldYC, velY: float64(c.game.background.oceanSpeed),
+					width: PROJECTILE_BOMB_SIZE, height: PROJECTILE_BOMB_SIZE}}
+			c.lastBombMilli[owner.id] = nowMilli
+			return &c.projectileUnitsB[i]
 		}
+	}
+	return nil
+}
 
+func (c *Projectile) detonateBomb(bunit *ProjectileUnit) {
+	bunit.active = false
+	centerX, centerY := bunit.Center()
+	c.game.explosion.addExplosion(centerX-EXPLOSION_W/2, centerY-EXPLOSION_H/2, 2)
+	for i := range c.blastUnits {
+		if !c.blastUnits[i].active {
+			c.blastUnits[i] = ProjectileUnit{damage: GROUND_BOMB_DAMAGE, owner: bunit.owner, active: true,
+				Movable: Movable{worldX: centerX - PROJECTILE_BOMB_BLAST/2, worldY: centerY - PROJECTILE_BOMB_BLAST/2,
+					width: PROJECTILE_BOMB_BLAST, height: PROJECTILE_BOMB_BLAST}}
+			return
+		}
 	}
-	return -1
+}
 
+func (c *Projectile) acquireTarget(fromX, fromY, dirX, dirY float64) int {
+	// nearest active entity in front of the given direction, -1 if none
+	target := -1
+	bestDistance := math.MaxFloat64
+	for i := range ENTITYS_MAX {
+		var eunit = &c.game.entity.entityUnits[i]
+		if !eunit.active {
+			continue
+		}
+		targetX, targetY := eunit.Center()
+		dx := targetX - fromX
+		dy := targetY - fromY
+		if dx*dirX+dy*dirY <= 0 {
+			continue
+		}
+		distance := math.Hypot(dx, dy)
+		if distance < bestDistance {
+			bestDistance = distance
+			target = i
+		}
+	}
+	return target
 }
 
-func (c *Projectile) checkUnitCollidePlayer(punit *ProjectileUnit) {
-	if !punit.active || punit.kind != PROJ_E {
-		return
+func (c *Projectile) steerHoming(punit *ProjectileUnit) {
+	centerX, centerY := punit.Center()
+	if punit.target < 0 || !c.game.entity.entityUnits[punit.target].active {
+		// target died or was never found, look again ahead of the missile
+		punit.target = c.acquireTarget(centerX, centerY, math.Cos(punit.heading), math.Sin(punit.heading))
+	}
+	if punit.target >= 0 {
+		var eunit = &c.game.entity.entityUnits[punit.target]
+		targetX, targetY := eunit.Center()
+		dx := targetX - centerX
+		dy := targetY - centerY
+		turn := math.Remainder(math.Atan2(dy, dx)-punit.heading, 2*math.Pi)
+		punit.heading += Clamp(-PROJECTILE_HOMING_TURN, PROJECTILE_HOMING_TURN, turn)
 	}
-	collided := Intersect(punit, c.game.player)
-	if collided && c.game.player.active {
-		c.game.player.takeDamage(PROJECTILE_PLAYER_DAMAGE)
+	speed := weaponTable[punit.weapon].speed
+	punit.velX = math.Cos(punit.heading) * speed
+	punit.velY = math.Sin(punit.heading) * speed
+}
 
-		punit.active = false
-		wx, wy, _, _ := c.game.player.Dimensions()
-		explosionKind := 2
+func (c *Projectile) addBodies(grid *SpatialGrid) {
+	for i := range PROJECTILES_P_MAX {
+		var punit = &c.projectileUnitsP[i]
+		if punit.active {
+			body := NewBody(LAYER_PLAYER_SHOT, i, &punit.active, &punit.Movable, nil)
+			body.damage = punit.damage
+			body.owner = punit.owner
+			body.ignore = punit.hitMask
+			body.swept, body.lastX, body.lastY = true, punit.lastX, punit.lastY
+			grid.insert(body)
+		}
+	}
+	for i := range PROJECTILES_MAX {
+		var eunit = &c.projectileUnitsE[i]
+		if eunit.active {
+			body := NewBody(LAYER_ENEMY_SHOT, i, &eunit.active, &eunit.Movable, nil)
+			body.damage = eunit.damage
+			body.swept, body.lastX, body.lastY = true, eunit.lastX, eunit.lastY
+			grid.insert(body)
+		}
+	}
+	for i := range c.blastUnits {
+		var blast = &c.blastUnits[i]
+		if blast.active {
+			body := NewBody(LAYER_BLAST, i, &blast.active, &blast.Movable, nil)
+			body.damage = blast.damage
+			body.owner = blast.owner
+			grid.insert(body)
+		}
+	}
+}
 
-		c.game.explosion.addExplosion(wx, wy, explosionKind)
-		c.game.incrementScore()
-		//fmt.Println("projectile hit entity")
+func (c *Projectile) onPlayerShotHit(self, other *Body) {
+	var punit = &c.projectileUnitsP[self.index]
+	centerX, centerY := punit.Center()
+	c.game.particles.burst(PARTICLE_SPARK, centerX, centerY, PROJECTILE_SPARKS)
+	if punit.pierce {
+		// piercing shots carry on through every entity along the path
+		punit.hitMask |= 1 << other.index
+	} else {
+		punit.active = false
+	}
+}
 
+func (c *Projectile) onEnemyShotHit(self, other *Body) {
+	if other.layer == LAYER_PLAYER && c.game.players[other.index].invulnerable() {
+		// shots pass straight through a blinking or rolling player
+		return
 	}
+	c.projectileUnitsE[self.index].active = false
+	centerX, centerY := c.projectileUnitsE[self.index].Center()
+	c.game.particles.burst(PARTICLE_SPARK, centerX, centerY, PROJECTILE_SPARKS)
+	wx, wy := other.movable.worldX, other.movable.worldY
+	explosionKind := 2
 
+	c.game.explosion.addExplosion(wx, wy, explosionKind)
+}
+
+func (c *Projectile) checkGrazes() {
+	// shots still flying after collisions that passed close by a player, swept like the hits
+	for _, player := range c.game.players {
+		if !player.active || player.invulnerable() {
+			continue
+		}
+		cx, cy, radius, ok := player.hitbox().circle(player)
+		if !ok {
+			continue
+		}
+		reach := radius + PROJECTILE_GRAZE_MARGIN
+		area := Movable{worldX: cx - reach, worldY: cy - reach, width: int(reach * 2), height: int(reach * 2)}
+		c.game.grid.query(&area, LAYER_ENEMY_SHOT, func(body *Body) bool {
+			var eunit = &c.projectileUnitsE[body.index]
+			if !eunit.active || eunit.grazed {
+				return true
+			}
+			swept := SweptBounds(&eunit.Movable, eunit.lastX, eunit.lastY)
+			x, y, width, height := swept.Dimensions()
+			if (HitRect{x, y, width, height}).touchesCircle(cx, cy, reach) {
+				eunit.grazed = true
+				player.graze()
+			}
+			return true
+		})
+	}
 }
 
 func (c *Projectile) loopProjectiles() {
-	for i := range PROJECTILES_MAX {
+	for i := range PROJECTILES_P_MAX {
 		// player
 		var punit = &c.projectileUnitsP[i]
 		if !c.projectileInBounds(punit) {
 			c.projectileUnitsP[i].active = false
 		} else {
+			if punit.homing && punit.active {
+				c.steerHoming(punit)
+			}
+			punit.lastX, punit.lastY = punit.worldX, punit.worldY
 			punit.Motion()
-			c.checkUnitCollideEntity(punit)
+			if punit.active && punit.exhaust.rate > 0 {
+				// out the back, opposite the way it is flying
+				centerX, centerY := punit.Center()
+				c.game.particles.emit(&punit.exhaust, centerX, centerY, math.Atan2(-punit.velY, -punit.velX), 1)
+			}
 		}
+	}
+	for i := range PROJECTILES_MAX {
 		// enemy
 		var eunit = &c.projectileUnitsE[i]
 		if !c.projectileInBounds(eunit) {
 			c.projectileUnitsE[i].active = false
 		} else {
+			eunit.lastX, eunit.lastY = eunit.worldX, eunit.worldY
 			eunit.Motion()
-			c.checkUnitCollidePlayer(eunit)
+		}
+	}
+	for i := range PROJECTILE_BOMBS_MAX {
+		var bunit = &c.projectileUnitsB[i]
+		if !bunit.active {
+			continue
+		}
+		bunit.Motion()
+		if bunit.fuse > 0 {
+			bunit.fuse -= 1
+		} else {
+			c.detonateBomb(bunit)
 		}
 	}
 
 }
 
 func (c *Projectile) Update() error {
-
+	// last tick's blasts have had their collision pass
+	c.blastUnits = [PROJECTILE_BOMBS_MAX]ProjectileUnit{}
 	c.loopProjectiles()
 	var err error
 	return err
@@ -11,6 +11,15 @@ import (
 //go:embed data/charmap_letters.cfg
 var CharmapLetters []byte
 
+//go:embed data/powerups.cfg
+var PowerupsCfg []byte
+
+//go:embed data/aircraft.cfg
+var AircraftCfg []byte
+
+//go:embed data/loot.cfg
+var LootCfg []byte
+
 // IMAGES
 
 //go:embed images/clouds1.png
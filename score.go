@@ -0,0 +1,73 @@
+package main
+
+const (
+	// kills closer together than this keep a chain going
+	SCORE_CHAIN_TICKS = 150
+	// every few kills in a chain raise the multiplier by one
+	SCORE_CHAIN_STEP     = 3
+	SCORE_MULTIPLIER_MAX = 8
+	SCORE_GROUND         = 20
+	SCORE_PICKUP         = 5
+	SCORE_MEDAL          = 100
+	SCORE_GRAZE          = 2
+	// graze meter gained per shot grazed, out of SCORE_GRAZE_METER_MAX
+	SCORE_GRAZE_CHARGE    = 5
+	SCORE_GRAZE_METER_MAX = 100
+)
+
+var (
+	// base score per enemy kind, the last plane of each row is the big one
+	entityScoreTable = [ENTITY_KINDS]int{
+		10, 10, 10, 10, 10, 30,
+		15, 15, 15, 15, 15, 40,
+		20, 20, 20, 20, 20, 50,
+	}
+)
+
+func (c *Player) multiplier() int {
+	return min(SCORE_MULTIPLIER_MAX, 1+c.chain/SCORE_CHAIN_STEP)
+}
+
+func (c *Player) addScore(points int) {
+	// an extra life for every GAME_POINTS_PER_NEW_LIFE crossed, however many points came at once
+	livesBefore := c.score / GAME_POINTS_PER_NEW_LIFE
+	c.score += points
+	c.lives += c.score/GAME_POINTS_PER_NEW_LIFE - livesBefore
+	c.updateText()
+}
+
+func (c *Player) scoreKill(points int) {
+	// a kill extends the chain, then scores at the raised multiplier
+	c.chain += 1
+	c.chainTicks = SCORE_CHAIN_TICKS
+	c.addScore(points * c.multiplier())
+}
+
+func (c *Player) graze() {
+	// a near miss scores a little without touching the chain
+	c.addScore(SCORE_GRAZE)
+	c.grazeMeter += SCORE_GRAZE_CHARGE
+	if c.grazeMeter >= SCORE_GRAZE_METER_MAX {
+		if c.smartBombs >= SMARTBOMB_MAX {
+			// stays full until there is room for the bomb
+			c.grazeMeter = SCORE_GRAZE_METER_MAX
+			return
+		}
+		c.grazeMeter = 0
+		c.addSmartBomb()
+		c.game.sound.PlaySFX(6)
+	}
+}
+
+func (c *Player) breakChain() {
+	c.chain, c.chainTicks = 0, 0
+}
+
+func (c *Player) updateChain() {
+	if c.chainTicks > 0 {
+		c.chainTicks -= 1
+		if c.chainTicks == 0 {
+			c.breakChain()
+		}
+	}
+}
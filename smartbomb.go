@@ -0,0 +1,87 @@
+package main
+
+import (
+	"image/color"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+const (
+	SMARTBOMB_DAMAGE       = 100
+	SMARTBOMB_MAX          = 5
+	SMARTBOMB_FLASH_TICKS  = 30
+	SMARTBOMB_CHAIN_EXTRA  = 6
+	SMARTBOMB_CHAIN_DELAY  = 5
+	SMARTBOMB_INTERVAL_MIN = 1000
+	SMARTBOMB_FLASH_ALPHA  = 0.8
+)
+
+// SmartBomb clears the screen of enemies and their projectiles.
+// It is drawn last so the flash covers everything else.
+type SmartBomb struct {
+	game        *Game
+	flashTicks  int
+	delayToggle func() bool
+}
+
+func NewSmartBomb(g *Game) *SmartBomb {
+	c := &SmartBomb{}
+	c.game = g
+	c.delayToggle = CreateDelayToggle(SMARTBOMB_INTERVAL_MIN)
+	return c
+}
+
+func (c *SmartBomb) detonate(player *Player) bool {
+	// true if the player had a bomb and it went off
+	if player.smartBombs <= 0 || !c.delayToggle() {
+		return false
+	}
+	player.smartBombs -= 1
+	c.flashTicks = SMARTBOMB_FLASH_TICKS
+
+	delay := 0
+	for i := range ENTITYS_MAX {
+		var eunit = &c.game.entity.entityUnits[i]
+		if !eunit.active {
+			continue
+		}
+		wx, wy := eunit.worldX, eunit.worldY
+		if c.game.entity.damageEntity(i, SMARTBOMB_DAMAGE, player) {
+			// a kill already blew up where it was
+			continue
+		}
+		c.game.explosion.addExplosionChain(wx, wy, 1, delay)
+		delay += SMARTBOMB_CHAIN_DELAY
+	}
+	for i := range PROJECTILES_MAX {
+		c.game.projectile.projectileUnitsE[i].active = false
+	}
+	// scatter a few more blasts across the screen so the bomb always looks big
+	for range SMARTBOMB_CHAIN_EXTRA {
+		worldX := float64(rand.IntN(WINDOW_WIDTH - EXPLOSION_W))
+		worldY := float64(rand.IntN(WINDOW_HEIGHT - EXPLOSION_H))
+		c.game.explosion.addExplosionChain(worldX, worldY, rand.IntN(2), delay)
+		delay += SMARTBOMB_CHAIN_DELAY
+	}
+	c.game.sound.PlaySFX(0)
+	return true
+}
+
+func (c *SmartBomb) Draw(screen *ebiten.Image) {
+	if c.flashTicks <= 0 {
+		return
+	}
+	// white fading out, color.RGBA is alpha premultiplied
+	level := uint8(0xff * SMARTBOMB_FLASH_ALPHA * float64(c.flashTicks) / SMARTBOMB_FLASH_TICKS)
+	flash := color.RGBA{level, level, level, level}
+	vector.DrawFilledRect(screen, 0, 0, WINDOW_WIDTH, WINDOW_HEIGHT, flash, false)
+}
+
+func (c *SmartBomb) Update() error {
+	if c.flashTicks > 0 {
+		c.flashTicks -= 1
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package main
+
+const (
+	SPATIAL_CELL_SIZE = 64
+	SPATIAL_BORDER    = 300
+	SPATIAL_COLS      = (WINDOW_WIDTH+SPATIAL_BORDER*2)/SPATIAL_CELL_SIZE + 1
+	SPATIAL_ROWS      = (WINDOW_HEIGHT+SPATIAL_BORDER*2)/SPATIAL_CELL_SIZE + 1
+)
+
+// SpatialGrid is a uniform grid broad phase over the collision bodies, rebuilt every tick.
+// Queries return candidates, callers still test the exact bounds.
+type SpatialGrid struct {
+	cells  [SPATIAL_COLS * SPATIAL_ROWS][]int
+	bodies []Body
+	// query stamp per body, stops bodies spanning several cells being visited twice
+	seen     []int
+	queryID  int
+	handlers map[uint32]func(self, other *Body)
+	contacts []Contact
+}
+
+func NewSpatialGrid() *SpatialGrid {
+	c := &SpatialGrid{}
+	c.bodies = []Body{}
+	c.seen = []int{}
+	c.handlers = map[uint32]func(self, other *Body){}
+	return c
+}
+
+func (c *SpatialGrid) cellRange(worldX, worldY, width, height int) (int, int, int, int) {
+	// first and last cell touched by a rectangle, clamped to the grid
+	col1 := Clamp(0, SPATIAL_COLS-1, (worldX+SPATIAL_BORDER)/SPATIAL_CELL_SIZE)
+	row1 := Clamp(0, SPATIAL_ROWS-1, (worldY+SPATIAL_BORDER)/SPATIAL_CELL_SIZE)
+	col2 := Clamp(0, SPATIAL_COLS-1, (worldX+width+SPATIAL_BORDER)/SPATIAL_CELL_SIZE)
+	row2 := Clamp(0, SPATIAL_ROWS-1, (worldY+height+SPATIAL_BORDER)/SPATIAL_CELL_SIZE)
+	return col1, row1, col2, row2
+}
+
+func (c *SpatialGrid) clear() {
+	for i := range c.cells {
+		c.cells[i] = c.cells[i][:0]
+	}
+	c.bodies = c.bodies[:0]
+	c.seen = c.seen[:0]
+}
+
+func (c *SpatialGrid) insert(body Body) {
+	bodyID := len(c.bodies)
+	c.bodies = append(c.bodies, body)
+	c.seen = append(c.seen, 0)
+	bounds := body.bounds()
+	worldX, worldY, width, height := bounds.Dimensions()
+	col1, row1, col2, row2 := c.cellRange(worldX, worldY, width, height)
+	for row := row1; row <= row2; row++ {
+		for col := col1; col <= col2; col++ {
+			cell := row*SPATIAL_COLS + col
+			c.cells[cell] = append(c.cells[cell], bodyID)
+		}
+	}
+}
+
+func (c *SpatialGrid) queryIDs(obj Collider, layers uint32, visit func(bodyID int) bool) {
+	// call visit with every body on one of layers near obj, until it returns false
+	c.queryID += 1
+	worldX, worldY, width, height := obj.Dimensions()
+	col1, row1, col2, row2 := c.cellRange(worldX, worldY, width, height)
+	for row := row1; row <= row2; row++ {
+		for col := col1; col <= col2; col++ {
+			for _, bodyID := range c.cells[row*SPATIAL_COLS+col] {
+				if c.bodies[bodyID].layer&layers == 0 || c.seen[bodyID] == c.queryID {
+					continue
+				}
+				c.seen[bodyID] = c.queryID
+				if !visit(bodyID) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *SpatialGrid) query(obj Collider, layers uint32, visit func(body *Body) bool) {
+	c.queryIDs(obj, layers, func(bodyID int) bool {
+		return visit(&c.bodies[bodyID])
+	})
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+)
+
+func newTestBody(layer uint32, worldX, worldY float64, width, height int) Body {
+	active := true
+	movable := &Movable{worldX: worldX, worldY: worldY, width: width, height: height}
+	return NewBody(layer, 0, &active, movable, nil)
+}
+
+func TestSpatialGridQueryVisitsOnce(t *testing.T) {
+	// a body across many cells is still visited once per query, and again by the next query
+	grid := NewSpatialGrid()
+	grid.insert(newTestBody(LAYER_ENTITY, 10, 10, SPATIAL_CELL_SIZE*4, SPATIAL_CELL_SIZE*4))
+	area := Movable{worldX: 0, worldY: 0, width: SPATIAL_CELL_SIZE * 6, height: SPATIAL_CELL_SIZE * 6}
+	for query := range 2 {
+		visits := 0
+		grid.query(&area, LAYER_ENTITY, func(body *Body) bool {
+			visits += 1
+			return true
+		})
+		if visits != 1 {
+			t.Fatalf("query %v visited the body %v times, want 1", query, visits)
+		}
+	}
+}
+
+func TestSpatialGridQueryLayers(t *testing.T) {
+	grid := NewSpatialGrid()
+	grid.insert(newTestBody(LAYER_ENTITY, 100, 100, 20, 20))
+	grid.insert(newTestBody(LAYER_PICKUP, 100, 100, 20, 20))
+	area := Movable{worldX: 90, worldY: 90, width: 40, height: 40}
+	grid.query(&area, LAYER_PICKUP, func(body *Body) bool {
+		if body.layer != LAYER_PICKUP {
+			t.Fatalf("query for pickups visited layer %v", body.layer)
+		}
+		return true
+	})
+}
+
+func TestSpatialGridClampsEdges(t *testing.T) {
+	// bodies far off the grid land in the edge cells instead of indexing out of range
+	far := []Movable{
+		{worldX: -5000, worldY: -5000, width: 10, height: 10},
+		{worldX: 5000, worldY: 5000, width: 10, height: 10},
+		{worldX: -5000, worldY: 5000, width: 10000, height: 10},
+	}
+	grid := NewSpatialGrid()
+	for _, m := range far {
+		grid.insert(newTestBody(LAYER_ENEMY_SHOT, m.worldX, m.worldY, m.width, m.height))
+		col1, row1, col2, row2 := grid.cellRange(m.Dimensions())
+		for _, cell := range []int{col1, col2} {
+			if cell < 0 || cell >= SPATIAL_COLS {
+				t.Fatalf("column %v out of range for %v", cell, m)
+			}
+		}
+		for _, cell := range []int{row1, row2} {
+			if cell < 0 || cell >= SPATIAL_ROWS {
+				t.Fatalf("row %v out of range for %v", cell, m)
+			}
+		}
+	}
+	for i := range far {
+		found := false
+		grid.query(&far[i], LAYER_ENEMY_SHOT, func(body *Body) bool {
+			found = found || body.movable.worldX == far[i].worldX && body.movable.worldY == far[i].worldY
+			return true
+		})
+		if !found {
+			t.Fatalf("body at %v not found in its edge cell", far[i])
+		}
+	}
+}
+
+func TestSpatialGridResolvesPairOnce(t *testing.T) {
+	grid := NewSpatialGrid()
+	hits := 0
+	grid.handle(LAYER_PLAYER_SHOT, func(self, other *Body) {
+		hits += 1
+	})
+	grid.insert(newTestBody(LAYER_PLAYER_SHOT, 100, 100, SPATIAL_CELL_SIZE*2, 10))
+	grid.insert(newTestBody(LAYER_ENTITY, 100, 100, SPATIAL_CELL_SIZE*2, 50))
+	grid.resolve()
+	if hits != 1 {
+		t.Fatalf("pair handled %v times, want 1", hits)
+	}
+}
+
+func benchmarkBodies(n int) []Body {
+	// shots and planes scattered over the screen, a tenth of them planes
+	rng := rand.New(rand.NewPCG(1, 2))
+	bodies := make([]Body, n)
+	for i := range bodies {
+		layer, size := LAYER_PLAYER_SHOT, 8
+		if i%10 == 0 {
+			layer, size = LAYER_ENTITY, 50
+		}
+		bodies[i] = newTestBody(layer, rng.Float64()*WINDOW_WIDTH, rng.Float64()*WINDOW_HEIGHT, size, size)
+	}
+	return bodies
+}
+
+func benchmarkHandler(self, other *Body) {}
+
+func BenchmarkSpatialGrid(b *testing.B) {
+	// the same bodies resolved through the grid and by testing every pair
+	for _, n := range []int{1000, 5000} {
+		bodies := benchmarkBodies(n)
+		b.Run(fmt.Sprintf("grid/%v", n), func(b *testing.B) {
+			grid := NewSpatialGrid()
+			grid.handle(LAYER_PLAYER_SHOT, benchmarkHandler)
+			for range b.N {
+				grid.clear()
+				for _, body := range bodies {
+					grid.insert(body)
+				}
+				grid.resolve()
+			}
+		})
+		b.Run(fmt.Sprintf("brute/%v", n), func(b *testing.B) {
+			// every pair tested and dispatched the way resolve does it
+			grid := NewSpatialGrid()
+			grid.handle(LAYER_PLAYER_SHOT, benchmarkHandler)
+			for range b.N {
+				for i := range bodies {
+					for j := i + 1; j < len(bodies); j++ {
+						bodyA, bodyB := &bodies[i], &bodies[j]
+						if !(bodyA.reacts(bodyB) || bodyB.reacts(bodyA)) || grid.touch(bodyA, bodyB) < 0 {
+							continue
+						}
+						if handler := grid.handlers[bodyA.layer]; nil != handler && bodyA.reacts(bodyB) {
+							handler(bodyA, bodyB)
+						}
+						if handler := grid.handlers[bodyB.layer]; nil != handler && bodyB.reacts(bodyA) {
+							handler(bodyB, bodyA)
+						}
+					}
+				}
+			}
+		})
+	}
+}
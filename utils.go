@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"log"
+	"math"
 	"os"
 	"strings"
 
@@ -26,9 +27,9 @@ func DrawImageAtF(src *ebiten.Image, dest *ebiten.Image, screenX, screenY float6
 	dest.DrawImage(src, op)
 }
 
-func (game *Game) WorldToScreen(worldX, worldY int) (int, int) {
-	screenX := (worldX - game.screenLocX)
-	screenY := (worldY - game.screenLocY)
+func (game *Game) WorldToScreen(worldX, worldY float64) (float64, float64) {
+	screenX := (worldX - float64(game.screenLocX))
+	screenY := (worldY - float64(game.screenLocY))
 	return screenX, screenY
 }
 
@@ -147,11 +148,48 @@ func getListOfLinesFromBytes(bytesArray []byte) []*string {
 	return linesList
 }
 
+func getConfigRecords(bytesArray []byte) [][]string {
+	// whitespace separated fields per line, blank lines and comments skipped
+	records := [][]string{}
+	for _, line := range getListOfLinesFromBytes(bytesArray) {
+		fields := strings.Fields(*line)
+		if len(fields) == 0 || fields[0][0] == UTILS_COMMENT {
+			continue
+		}
+		records = append(records, fields)
+	}
+	return records
+}
+
 type Collider interface {
 	// worldx, worldy width, height
 	Dimensions() (int, int, int, int)
 }
 
+func (m *Movable) Motion() {
+	// apply acceleration, limit to max speed if one is set, then move
+	m.velX += m.accX
+	m.velY += m.accY
+	if m.maxSpeed > 0 {
+		speed := math.Hypot(m.velX, m.velY)
+		if speed > m.maxSpeed {
+			m.velX *= m.maxSpeed / speed
+			m.velY *= m.maxSpeed / speed
+		}
+	}
+	m.worldX += m.velX
+	m.worldY += m.velY
+}
+
+func (m *Movable) Center() (float64, float64) {
+	return m.worldX + float64(m.width)/2, m.worldY + float64(m.height)/2
+}
+
+func (m *Movable) Dimensions() (int, int, int, int) {
+	// collision works on whole pixels
+	return int(math.Round(m.worldX)), int(math.Round(m.worldY)), m.width, m.height
+}
+
 func Intersect(objA, objB Collider) bool {
 	//true if colliding
 	ax1, ay1, aw, ah := objA.Dimensions()
@@ -167,6 +205,17 @@ func Intersect(objA, objB Collider) bool {
 
 }
 
+func AimVelocity(fromX, fromY, toX, toY, speed float64) (float64, float64) {
+	// velocity of magnitude speed pointing from one point to another
+	dx := toX - fromX
+	dy := toY - fromY
+	distance := math.Hypot(dx, dy)
+	if distance == 0 {
+		return 0, speed
+	}
+	return dx / distance * speed, dy / distance * speed
+}
+
 func Pulser(tickPeriod int) func() bool {
 	//returns true for a series of ticks, then false ,then repeat
 	var currentTick = 0
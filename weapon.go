@@ -0,0 +1,272 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	WEAPON_KINDS        = 5
+	WEAPON_LEVEL_MAX    = 3
+	WEAPON_START        = WEAPON_TWIN_ROCKETS
+	WEAPON_HEAT_MAX     = 100
+	WEAPON_HEAT_COOL    = 0.4
+	WEAPON_HEAT_RECOVER = 40
+	WEAPON_CHARGE_SPEED = 5
+	WEAPON_CHARGE_SIZE  = 12
+	WEAPON_CHARGE_GROW  = 36
+	WEAPON_CHARGE_DMG   = 20
+	WEAPON_CHARGE_BONUS = 130
+)
+
+const (
+	WEAPON_MACHINEGUN = iota
+	WEAPON_TWIN_ROCKETS
+	WEAPON_SPREAD
+	WEAPON_HOMING
+	WEAPON_LASER
+)
+
+type WeaponDef struct {
+	name          string
+	cooldownMS    int64
+	damage        int
+	speed         float64
+	width, height int
+	pierce        bool
+	homing        bool
+	// holding fire charges a piercing shot, weapons that can't charge keep firing instead
+	charge bool
+	// rounds per clip, reserve rounds (0 is unlimited), heat added per shot
+	clipSize    int
+	ammoMax     int
+	reloadMS    int64
+	heatPerShot float64
+}
+
+var weaponTable = [WEAPON_KINDS]WeaponDef{
+	WEAPON_MACHINEGUN:   {"GUN", 120, 8, 6, 4, 10, false, false, false, 30, 300, 1200, 6},
+	WEAPON_TWIN_ROCKETS: {"ROCKETS", 500, 25, 3, PROJECTILE_W, PROJECTILE_H, false, false, true, 8, 80, 1500, 10},
+	WEAPON_SPREAD:       {"SPREAD", 350, 10, 4, 8, 8, false, false, true, 12, 120, 1400, 12},
+	WEAPON_HOMING:       {"HOMING", 700, 30, 3, PROJECTILE_W, PROJECTILE_H, false, true, true, 4, 40, 2000, 15},
+	WEAPON_LASER:        {"LASER", 400, 15, 10, 4, 40, true, false, false, 10, 0, 1000, 25},
+}
+
+type Weapon struct {
+	game           *Game
+	player         *Player
+	kind           int
+	levels         [WEAPON_KINDS]int
+	lastFiredMilli [WEAPON_KINDS]int64
+	clip           [WEAPON_KINDS]int
+	ammo           [WEAPON_KINDS]int
+	reloadMilli    [WEAPON_KINDS]int64
+	heat           float64
+	overheated     bool
+}
+
+func NewWeapon(g *Game) *Weapon {
+	c := &Weapon{}
+	c.game = g
+	c.reset()
+	return c
+}
+
+func (c *Weapon) reset() {
+	c.kind = WEAPON_START
+	for i := range c.levels {
+		c.levels[i] = 1
+	}
+	c.lastFiredMilli = [WEAPON_KINDS]int64{}
+	c.refill()
+}
+
+func (c *Weapon) refill() {
+	for kind := range weaponTable {
+		c.clip[kind] = weaponTable[kind].clipSize
+		c.ammo[kind] = weaponTable[kind].ammoMax
+	}
+	c.reloadMilli = [WEAPON_KINDS]int64{}
+	c.heat = 0
+	c.overheated = false
+}
+
+func (c *Weapon) addAmmo(fraction float64) {
+	// top up every reserve by a fraction of its maximum
+	for kind := range weaponTable {
+		ammoMax := weaponTable[kind].ammoMax
+		c.ammo[kind] = Clamp(0, ammoMax, c.ammo[kind]+int(float64(ammoMax)*fraction))
+	}
+}
+
+func (c *Weapon) reloading() bool {
+	return c.reloadMilli[c.kind] != 0
+}
+
+func (c *Weapon) reload() {
+	def := c.def()
+	if c.reloading() || c.clip[c.kind] >= def.clipSize {
+		return
+	}
+	if def.ammoMax > 0 && c.ammo[c.kind] == 0 {
+		return
+	}
+	c.reloadMilli[c.kind] = time.Now().UnixMilli() + def.reloadMS
+	c.game.sound.PlaySFX(6)
+}
+
+func (c *Weapon) finishReloads() {
+	var nowMilli = time.Now().UnixMilli()
+	for kind := range weaponTable {
+		if c.reloadMilli[kind] == 0 || nowMilli < c.reloadMilli[kind] {
+			continue
+		}
+		c.reloadMilli[kind] = 0
+		def := &weaponTable[kind]
+		rounds := def.clipSize - c.clip[kind]
+		if def.ammoMax > 0 {
+			rounds = min(rounds, c.ammo[kind])
+			c.ammo[kind] -= rounds
+		}
+		c.clip[kind] += rounds
+	}
+}
+
+func (c *Weapon) Update() {
+	if !c.game.ammoLimited() {
+		return
+	}
+	c.finishReloads()
+	c.heat = max(0, c.heat-WEAPON_HEAT_COOL)
+	if c.overheated && c.heat < WEAPON_HEAT_RECOVER {
+		c.overheated = false
+	}
+}
+
+func (c *Weapon) canFire() bool {
+	if !c.game.ammoLimited() {
+		return true
+	}
+	if c.overheated || c.reloading() {
+		return false
+	}
+	if c.clip[c.kind] == 0 {
+		c.reload()
+		return false
+	}
+	return true
+}
+
+func (c *Weapon) spendRound() {
+	if !c.game.ammoLimited() {
+		return
+	}
+	c.clip[c.kind] -= 1
+	c.heat += c.def().heatPerShot
+	if c.heat >= WEAPON_HEAT_MAX {
+		c.heat = WEAPON_HEAT_MAX
+		c.overheated = true
+		c.game.sound.PlaySFX(3)
+	}
+	if c.clip[c.kind] == 0 {
+		c.reload()
+	}
+}
+
+func (c *Weapon) def() *WeaponDef {
+	return &weaponTable[c.kind]
+}
+
+func (c *Weapon) cooldownMS() int64 {
+	if nil != c.player && c.player.powerups.active(POWERUP_RAPID) {
+		return int64(float64(c.def().cooldownMS) * POWERUP_RAPID_FACTOR)
+	}
+	return c.def().cooldownMS
+}
+
+func (c *Weapon) damage(base int) int {
+	if nil != c.player && c.player.powerups.active(POWERUP_DOUBLE) {
+		return base * POWERUP_DAMAGE_FACTOR
+	}
+	return base
+}
+
+func (c *Weapon) level() int {
+	return c.levels[c.kind]
+}
+
+func (c *Weapon) cycle(step int) {
+	c.kind = (c.kind + step + WEAPON_KINDS) % WEAPON_KINDS
+}
+
+func (c *Weapon) upgrade() {
+	c.levels[c.kind] = Clamp(1, WEAPON_LEVEL_MAX, c.levels[c.kind]+1)
+}
+
+func (c *Weapon) fire(worldX, worldY float64) bool {
+	// fire the current weapon from the nose of the aircraft, true if any shot left
+	var nowMilli = time.Now().UnixMilli()
+	def := c.def()
+	if nowMilli-c.lastFiredMilli[c.kind] < c.cooldownMS() || !c.canFire() {
+		return false
+	}
+	level := c.level()
+	noseX := worldX + PLAYER_SIZE/2
+	noseY := worldY + PROJECTILE_OFFSET_Y
+	fired := false
+	shoot := func(offsetX, velX float64, width, damage int) {
+		punit := c.game.projectile.addPlayerProjectile(noseX+offsetX-float64(width)/2, noseY, velX, -def.speed, c.kind)
+		if nil != punit {
+			punit.owner = c.player
+			punit.width = width
+			punit.damage = c.damage(damage)
+			fired = true
+		}
+	}
+	switch c.kind {
+	case WEAPON_MACHINEGUN:
+		// one extra barrel per level
+		for i := range level {
+			offsetX := float64(i*2-(level-1)) * 6
+			shoot(offsetX, 0, def.width, def.damage)
+		}
+	case WEAPON_TWIN_ROCKETS:
+		for _, side := range []float64{-1, 1} {
+			shoot(side*20, 0, def.width, def.damage+(level-1)*10)
+		}
+	case WEAPON_SPREAD:
+		// fan of 3, 5 or 7 shots
+		for i := -level; i <= level; i++ {
+			velX := float64(i) * 0.8
+			shoot(float64(i)*4, velX, def.width, def.damage)
+		}
+	case WEAPON_HOMING:
+		for i := range level {
+			offsetX := float64(i*2-(level-1)) * 15
+			shoot(offsetX, 0, def.width, def.damage)
+		}
+	case WEAPON_LASER:
+		shoot(0, 0, def.width*level, def.damage*level)
+	}
+	if fired {
+		c.lastFiredMilli[c.kind] = nowMilli
+		c.spendRound()
+	}
+	return fired
+}
+
+func (c *Weapon) fireCharged(worldX, worldY, fraction float64) bool {
+	// piercing shot whose size and damage grow with the charge fraction
+	if !c.canFire() {
+		return false
+	}
+	size := WEAPON_CHARGE_SIZE + int(WEAPON_CHARGE_GROW*fraction)
+	noseX := worldX + PLAYER_SIZE/2 - float64(size)/2
+	noseY := worldY + PROJECTILE_OFFSET_Y - float64(size)/2
+	damage := c.damage(WEAPON_CHARGE_DMG + int(WEAPON_CHARGE_BONUS*fraction))
+	if nil == c.game.projectile.addChargedProjectile(noseX, noseY, size, damage, c.player) {
+		return false
+	}
+	c.lastFiredMilli[c.kind] = time.Now().UnixMilli()
+	c.spendRound()
+	return true
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	WINGMAN_MAX    = 2
+	WINGMAN_SIZE   = 40
+	WINGMAN_HEALTH = 2
+	// fraction of the way to its slot a wingman closes each tick
+	WINGMAN_FOLLOW = 0.15
+	// orbiting wingmen circle the player instead of holding formation
+	WINGMAN_ORBIT        = false
+	WINGMAN_ORBIT_RADIUS = 70
+	WINGMAN_ORBIT_SPEED  = 0.05
+	WINGMAN_SHOT_DAMAGE  = 5
+	WINGMAN_SHOT_SPEED   = 6
+	WINGMAN_DEBRIS       = 16
+)
+
+var (
+	// slot offsets from the player's center, off the wingtips and tucked in behind when sprinting
+	wingmanFormation = [WINGMAN_MAX][2]float64{{-70, 30}, {70, 30}}
+	wingmanTrail     = [WINGMAN_MAX][2]float64{{-20, 70}, {20, 110}}
+)
+
+type Wingmen struct {
+	game *Game
+	// per aircraft, wingmen fly the same plane as their player
+	images [][]*ebiten.Image
+	units  [GAME_PLAYERS][WINGMAN_MAX]WingmanUnit
+	// shared orbit angle so orbiting wingmen stay evenly spaced
+	orbitAngle float64
+}
+
+type WingmanUnit struct {
+	health int
+	active bool
+	Movable
+}
+
+func NewWingmen(g *Game) *Wingmen {
+	c := &Wingmen{}
+	c.game = g
+	c.initImages()
+	g.grid.handle(LAYER_WINGMAN, c.onHit)
+	return c
+}
+
+func (c *Wingmen) initImages() {
+	// small copies of every plane's banking frames
+	for _, frames := range c.game.hangar.images {
+		images := []*ebiten.Image{}
+		for _, img := range frames {
+			images = append(images, ScaleImage(img, WINGMAN_SIZE, WINGMAN_SIZE))
+		}
+		c.images = append(c.images, images)
+	}
+}
+
+func (c *Wingmen) add(player *Player) bool {
+	// a new wingman starts on the player, false if every slot is flying
+	for i := range c.units[player.id] {
+		var wunit = &c.units[player.id][i]
+		if !wunit.active {
+			centerX, centerY := player.Center()
+			*wunit = WingmanUnit{health: WINGMAN_HEALTH, active: true}
+			wunit.worldX, wunit.worldY = centerX-WINGMAN_SIZE/2, centerY-WINGMAN_SIZE/2
+			wunit.width, wunit.height = WINGMAN_SIZE, WINGMAN_SIZE
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Wingmen) clear(player *Player) {
+	c.units[player.id] = [WINGMAN_MAX]WingmanUnit{}
+}
+
+func (c *Wingmen) slot(player *Player, index int) (float64, float64) {
+	// where a wingman wants to be, top left corner in world coords
+	centerX, centerY := player.Center()
+	offset := wingmanFormation[index]
+	switch {
+	case WINGMAN_ORBIT:
+		angle := c.orbitAngle + 2*math.Pi*float64(index)/WINGMAN_MAX
+		radius := float64(WINGMAN_ORBIT_RADIUS)
+		if player.sprinting {
+			radius /= 2
+		}
+		offset = [2]float64{radius * math.Cos(angle), radius * math.Sin(angle)}
+	case player.sprinting:
+		offset = wingmanTrail[index]
+	}
+	return centerX + offset[0] - WINGMAN_SIZE/2, centerY + offset[1] - WINGMAN_SIZE/2
+}
+
+func (c *Wingmen) fire(player *Player) {
+	// one gun round each, alongside whatever the player fired
+	for i := range c.units[player.id] {
+		var wunit = &c.units[player.id][i]
+		if !wunit.active {
+			continue
+		}
+		centerX, _ := wunit.Center()
+		punit := c.game.projectile.addPlayerProjectile(centerX, wunit.worldY, 0, -WINGMAN_SHOT_SPEED, WEAPON_MACHINEGUN)
+		if nil != punit {
+			punit.worldX -= float64(punit.width) / 2
+			punit.maxSpeed = WINGMAN_SHOT_SPEED
+			punit.owner = player
+			punit.damage = player.weapon.damage(WINGMAN_SHOT_DAMAGE)
+		}
+	}
+}
+
+func (c *Wingmen) addBodies(grid *SpatialGrid) {
+	for _, player := range c.game.players {
+		if !player.active {
+			continue
+		}
+		for i := range c.units[player.id] {
+			var wunit = &c.units[player.id][i]
+			if wunit.active {
+				grid.insert(NewBody(LAYER_WINGMAN, player.id*WINGMAN_MAX+i, &wunit.active, &wunit.Movable, nil))
+			}
+		}
+	}
+}
+
+func (c *Wingmen) onHit(self, other *Body) {
+	var wunit = &c.units[self.index/WINGMAN_MAX][self.index%WINGMAN_MAX]
+	wunit.health -= 1
+	if wunit.health <= 0 {
+		wunit.active = false
+		c.game.explosion.addExplosion(wunit.worldX, wunit.worldY, 2)
+		centerX, centerY := wunit.Center()
+		c.game.particles.burst(PARTICLE_DEBRIS, centerX, centerY, WINGMAN_DEBRIS)
+		c.game.sound.PlaySFX(1)
+	}
+}
+
+func (c *Wingmen) Draw(screen *ebiten.Image) {
+	for _, player := range c.game.players {
+		if !player.active {
+			continue
+		}
+		tint := playerTints[player.id]
+		for i := range c.units[player.id] {
+			var wunit = &c.units[player.id][i]
+			if !wunit.active {
+				continue
+			}
+			op := &ebiten.DrawImageOptions{}
+			screenX, screenY := c.game.WorldToScreen(wunit.worldX, wunit.worldY)
+			op.GeoM.Translate(screenX, screenY)
+			op.ColorScale.Scale(tint[0], tint[1], tint[2], 1)
+			// bank along with the player
+			screen.DrawImage(c.images[player.aircraftID][player.imageID], op)
+		}
+	}
+}
+
+func (c *Wingmen) Update() error {
+	var err error
+	if c.game.mode != PLAY {
+		return err
+	}
+	c.orbitAngle = math.Mod(c.orbitAngle+WINGMAN_ORBIT_SPEED, 2*math.Pi)
+	for _, player := range c.game.players {
+		if !player.active {
+			continue
+		}
+		for i := range c.units[player.id] {
+			var wunit = &c.units[player.id][i]
+			if !wunit.active {
+				continue
+			}
+			slotX, slotY := c.slot(player, i)
+			wunit.worldX += (slotX - wunit.worldX) * WINGMAN_FOLLOW
+			wunit.worldY += (slotY - wunit.worldY) * WINGMAN_FOLLOW
+		}
+	}
+	return err
+}